@@ -0,0 +1,346 @@
+// Headless batch runner: plays many seeded battles with no broadcaster,
+// websocket or sleeping and prints per-tribe win rates for balancing.
+//
+//	go run ./cmd/worldbox-batch -map vertical -runs 1000 -peace 600
+//	go run ./cmd/worldbox-batch -terrain mymap.json -format json
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Scrimzay/worldboxsim/internal/world"
+)
+
+// Same shape as the init_custom_map websocket action
+type customMapFile struct {
+	Width            int               `json:"width"` // Optional, defaults to -width/-height
+	Height           int               `json:"height"`
+	Terrain          []uint8           `json:"terrain"`
+	TribeAssignments map[string]string `json:"tribeAssignments"`
+}
+
+type runResult struct {
+	Seed       int64
+	Winner     string // Tribe ID ("1+3" for allies winning together), "draw" or "" on timeout
+	WarTicks   int64
+	Survivors  int
+	TribeNames map[uint8]string
+}
+
+type TribeSummary struct {
+	Tribe        string  `json:"tribe"`
+	Name         string  `json:"name"`
+	Wins         int     `json:"wins"`
+	WinRate      float64 `json:"winRate"`
+	AvgWarTicks  float64 `json:"avgWarTicks"`  // Over this tribe's wins
+	AvgSurvivors float64 `json:"avgSurvivors"` // Over this tribe's wins
+}
+
+type Summary struct {
+	Map                 string         `json:"map"`
+	Runs                int            `json:"runs"`
+	FirstSeed           int64          `json:"firstSeed"`
+	PeaceTicks          int            `json:"peaceTicks"`
+	MaxWarTicks         int64          `json:"maxWarTicks"`
+	Draws               int            `json:"draws"`
+	Timeouts            int            `json:"timeouts"`
+	TimeoutAvgSurvivors float64        `json:"timeoutAvgSurvivors"` // Over timeouts, how many were still fighting
+	AvgWarTicks         float64        `json:"avgWarTicks"`         // Over decided battles (wins + draws)
+	AvgSurvivors        float64        `json:"avgSurvivors"`        // Over decided battles (wins + draws)
+	Tribes              []TribeSummary `json:"tribes"`
+}
+
+func main() {
+	mapName := flag.String("map", "vertical", "map builder: vertical, northsouth, fourquadrants or islands")
+	terrainPath := flag.String("terrain", "", "custom map JSON file ({\"terrain\": [...], \"tribeAssignments\": {...}}), overrides -map")
+	width := flag.Int("width", world.DefaultGridSize, "grid width")
+	height := flag.Int("height", world.DefaultGridSize, "grid height")
+	runs := flag.Int("runs", 100, "number of battles (one per seed)")
+	firstSeed := flag.Int64("seed", 1, "seed of the first battle, later battles use seed+1, seed+2, ...")
+	peaceTicks := flag.Int("peace", 600, "peace ticks before StartWar")
+	maxWarTicks := flag.Int64("max-war", 20000, "war ticks before a battle counts as a timeout")
+	workers := flag.Int("workers", 0, "parallel battles (0 = one per CPU)")
+	racesPath := flag.String("races", "", "races JSON file (default: built-in races)")
+	diplomacyAI := flag.Bool("diplomacy", false, "let the diplomacy AI form and break alliances during the war")
+	buyRanks := flag.Bool("buy-ranks", false, "let tribes buy ranks with wood in peace, on top of earning them with kills")
+	format := flag.String("format", "csv", "output format: csv or json")
+	verbose := flag.Bool("v", false, "keep world package logging")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	if *racesPath != "" {
+		if err := world.LoadRaceConfig(*racesPath); err != nil {
+			fatalf("load races: %v", err)
+		}
+	}
+
+	var custom *customMapFile
+	if *terrainPath != "" {
+		data, err := os.ReadFile(*terrainPath)
+		if err != nil {
+			fatalf("read terrain file: %v", err)
+		}
+		custom = &customMapFile{}
+		if err := json.Unmarshal(data, custom); err != nil {
+			fatalf("parse terrain file: %v", err)
+		}
+		*mapName = "custommap"
+		if custom.Width > 0 && custom.Height > 0 {
+			*width, *height = custom.Width, custom.Height
+		}
+	}
+
+	if err := world.ValidGridSize(*width, *height); err != nil {
+		fatalf("%v", err)
+	}
+
+	if *workers <= 0 {
+		*workers = runtime.NumCPU()
+	}
+
+	seeds := make(chan int64)
+	results := make(chan runResult)
+
+	var wg sync.WaitGroup
+	for i := 0; i < *workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for seed := range seeds {
+				res, err := runBattle(seed, *mapName, custom, *width, *height, *peaceTicks, *maxWarTicks, *diplomacyAI, *buyRanks)
+				if err != nil {
+					fatalf("seed %d: %v", seed, err)
+				}
+				results <- res
+			}
+		}()
+	}
+
+	go func() {
+		for i := 0; i < *runs; i++ {
+			seeds <- *firstSeed + int64(i)
+		}
+		close(seeds)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	all := []runResult{}
+	for res := range results {
+		all = append(all, res)
+	}
+
+	summary := summarize(all, *mapName, *firstSeed, *peaceTicks, *maxWarTicks)
+	if summary.Timeouts > 0 {
+		fmt.Fprintf(os.Stderr, "worldbox-batch: %d of %d battles still running after -max-war %d ticks, counted as timeouts\n", summary.Timeouts, summary.Runs, *maxWarTicks)
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(summary); err != nil {
+			fatalf("write json: %v", err)
+		}
+
+	case "csv":
+		if err := writeCSV(os.Stdout, summary); err != nil {
+			fatalf("write csv: %v", err)
+		}
+
+	default:
+		fatalf("unknown format %q (want csv or json)", *format)
+	}
+}
+
+// Builds a world, runs the peace phase, starts the war and steps until someone wins
+func runBattle(seed int64, mapName string, custom *customMapFile, width, height, peaceTicks int, maxWarTicks int64, diplomacyAI, buyRanks bool) (runResult, error) {
+	w := world.NewWithSeed(seed)
+	w.SetDiplomacyAI(diplomacyAI)
+	w.SetBuyRanks(buyRanks)
+	if err := w.Resize(width, height); err != nil {
+		return runResult{}, err
+	}
+	if custom != nil {
+		if !w.InitCustomMap(custom.Terrain, custom.TribeAssignments) {
+			return runResult{}, fmt.Errorf("invalid custom map")
+		}
+	} else {
+		w.InitMap(mapName)
+	}
+
+	for i := 0; i < peaceTicks; i++ {
+		w.Update()
+	}
+
+	// Same order as the start_war websocket action
+	w.ConvertBordersToTerrain()
+	w.StartWar()
+
+	warStart := w.Tick()
+	for !w.IsGameOver() && w.Tick()-warStart < maxWarTicks {
+		w.Update()
+	}
+
+	res := runResult{
+		Seed:       seed,
+		Winner:     w.GetWinner(),
+		WarTicks:   w.Tick() - warStart,
+		TribeNames: make(map[uint8]string),
+	}
+
+	for _, n := range w.CountEntitiesByTribe() {
+		res.Survivors += n
+	}
+
+	w.Mu.RLock()
+	for tribe, cfg := range w.Tribes {
+		res.TribeNames[tribe] = cfg.Name
+	}
+	w.Mu.RUnlock()
+
+	return res, nil
+}
+
+func summarize(all []runResult, mapName string, firstSeed int64, peaceTicks int, maxWarTicks int64) Summary {
+	s := Summary{
+		Map:         mapName,
+		Runs:        len(all),
+		FirstSeed:   firstSeed,
+		PeaceTicks:  peaceTicks,
+		MaxWarTicks: maxWarTicks,
+	}
+
+	type acc struct {
+		name      string
+		wins      int
+		ticks     int64
+		survivors int
+	}
+	tribes := make(map[string]*acc)
+
+	decided := 0
+	var totalTicks int64
+	totalSurvivors := 0
+	timeoutSurvivors := 0
+
+	for _, res := range all {
+		// Every tribe that took part gets a row, even with zero wins
+		for id, name := range res.TribeNames {
+			key := strconv.Itoa(int(id))
+			if tribes[key] == nil {
+				tribes[key] = &acc{name: name}
+			}
+		}
+
+		switch res.Winner {
+		case "":
+			s.Timeouts++
+			timeoutSurvivors += res.Survivors
+			continue
+
+		case "draw":
+			s.Draws++
+
+		default:
+			// Allies winning together each get the win
+			for _, winner := range strings.Split(res.Winner, "+") {
+				a := tribes[winner]
+				if a == nil {
+					a = &acc{name: "Tribe " + winner}
+					tribes[winner] = a
+				}
+				a.wins++
+				a.ticks += res.WarTicks
+				a.survivors += res.Survivors
+			}
+		}
+
+		decided++
+		totalTicks += res.WarTicks
+		totalSurvivors += res.Survivors
+	}
+
+	if decided > 0 {
+		s.AvgWarTicks = float64(totalTicks) / float64(decided)
+		s.AvgSurvivors = float64(totalSurvivors) / float64(decided)
+	}
+	if s.Timeouts > 0 {
+		s.TimeoutAvgSurvivors = float64(timeoutSurvivors) / float64(s.Timeouts)
+	}
+
+	keys := make([]string, 0, len(tribes))
+	for k := range tribes {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, _ := strconv.Atoi(keys[i])
+		b, _ := strconv.Atoi(keys[j])
+		return a < b
+	})
+
+	for _, k := range keys {
+		a := tribes[k]
+		ts := TribeSummary{Tribe: k, Name: a.name, Wins: a.wins}
+		if s.Runs > 0 {
+			ts.WinRate = float64(a.wins) / float64(s.Runs)
+		}
+		if a.wins > 0 {
+			ts.AvgWarTicks = float64(a.ticks) / float64(a.wins)
+			ts.AvgSurvivors = float64(a.survivors) / float64(a.wins)
+		}
+		s.Tribes = append(s.Tribes, ts)
+	}
+
+	return s
+}
+
+// One row per tribe, then draw/timeout/all rows so the file stays a single table.
+// The timeout row's ticks are -max-war, its survivors those still fighting
+func writeCSV(out io.Writer, s Summary) error {
+	cw := csv.NewWriter(out)
+	cw.Write([]string{"tribe", "name", "runs", "wins", "win_rate", "avg_war_ticks", "avg_survivors"})
+
+	runs := strconv.Itoa(s.Runs)
+	rate := func(n int) string {
+		if s.Runs == 0 {
+			return "0"
+		}
+		return strconv.FormatFloat(float64(n)/float64(s.Runs), 'f', 4, 64)
+	}
+	num := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', 2, 64)
+	}
+
+	for _, t := range s.Tribes {
+		cw.Write([]string{t.Tribe, t.Name, runs, strconv.Itoa(t.Wins), rate(t.Wins), num(t.AvgWarTicks), num(t.AvgSurvivors)})
+	}
+	cw.Write([]string{"draw", "", runs, strconv.Itoa(s.Draws), rate(s.Draws), "", ""})
+	cw.Write([]string{"timeout", "", runs, strconv.Itoa(s.Timeouts), rate(s.Timeouts), strconv.FormatInt(s.MaxWarTicks, 10), num(s.TimeoutAvgSurvivors)})
+	cw.Write([]string{"all", s.Map, runs, "", "", num(s.AvgWarTicks), num(s.AvgSurvivors)})
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "worldbox-batch: "+format+"\n", args...)
+	os.Exit(1)
+}
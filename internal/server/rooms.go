@@ -0,0 +1,120 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Scrimzay/worldboxsim/internal/world"
+)
+
+// One independent simulation: its own world, broadcaster and clients
+type Room struct {
+	mu          sync.Mutex // Guards MapName while a map is (re)built
+	ID          string
+	MapName     string
+	World       *world.World
+	Broadcaster *world.Broadcaster
+	idleSince   time.Time // Zero while clients are connected
+}
+
+type RoomManager struct {
+	mu          sync.Mutex
+	rooms       map[string]*Room
+	idleTimeout time.Duration // How long a room may sit with no clients before teardown
+}
+
+func NewRoomManager(idleTimeout time.Duration) *RoomManager {
+	return &RoomManager{
+		rooms:       make(map[string]*Room),
+		idleTimeout: idleTimeout,
+	}
+}
+
+// Random hex id for a new room
+func NewRoomID() string {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		// crypto/rand never fails on supported platforms, fall back to time just in case
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+
+	return hex.EncodeToString(buf)
+}
+
+func (m *RoomManager) Get(id string) (*Room, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	room, ok := m.rooms[id]
+	return room, ok
+}
+
+// Returns the room for id, creating (and starting) it if needed.
+// created is true when a fresh world was made
+func (m *RoomManager) GetOrCreate(id string) (room *Room, created bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if room, ok := m.rooms[id]; ok {
+		return room, false
+	}
+
+	gameWorld := world.New()
+	broadcaster := world.NewBroadcaster(gameWorld)
+	go broadcaster.Run()
+
+	room = &Room{
+		ID:          id,
+		World:       gameWorld,
+		Broadcaster: broadcaster,
+		idleSince:   time.Now(), // Reaped if nobody ever connects
+	}
+	m.rooms[id] = room
+	log.Printf("Room %s created (%d active)", id, len(m.rooms))
+
+	return room, true
+}
+
+func (m *RoomManager) Count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.rooms)
+}
+
+// Tears down every room that has had no clients for longer than idleTimeout
+func (m *RoomManager) reapIdle(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, room := range m.rooms {
+		if room.Broadcaster.ClientCount() > 0 {
+			room.idleSince = time.Time{}
+			continue
+		}
+
+		if room.idleSince.IsZero() {
+			room.idleSince = now
+			continue
+		}
+
+		if now.Sub(room.idleSince) >= m.idleTimeout {
+			room.Broadcaster.Stop()
+			delete(m.rooms, id)
+			log.Printf("Room %s idle for %v, torn down (%d active)", id, now.Sub(room.idleSince).Round(time.Second), len(m.rooms))
+		}
+	}
+}
+
+// Periodically reaps idle rooms, blocks forever (run in a goroutine)
+func (m *RoomManager) RunJanitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		m.reapIdle(now)
+	}
+}
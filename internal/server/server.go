@@ -2,22 +2,25 @@ package server
 
 import (
 	"log"
+	"net/http"
+	"strconv"
 
 	"github.com/Scrimzay/worldboxsim/internal/world"
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter(broadcaster *world.Broadcaster, gameWorld *world.World) *gin.Engine {
+func SetupRouter(rooms *RoomManager, snapshots *SnapshotStore) *gin.Engine {
 	r := gin.Default()
 	r.LoadHTMLGlob("**/*.html")
 	r.Static("/static", "./static")
 
 	r.GET("/", indexHandler)
-	r.GET("/play/:mapName", playHandler(gameWorld, broadcaster))
+	r.GET("/play/:mapName", playHandler(rooms))
 	r.GET("/help", helpHandler)
+	r.GET("/races", racesHandler)
 
-	r.GET("/wss", HandleWebsocket(broadcaster, gameWorld))
-	r.GET("/ws", HandleWebsocket(broadcaster, gameWorld))
+	r.GET("/wss", HandleWebsocket(rooms, snapshots))
+	r.GET("/ws", HandleWebsocket(rooms, snapshots))
 
 	return r
 }
@@ -30,15 +33,68 @@ func helpHandler(c *gin.Context) {
 	c.HTML(200, "help.html", nil)
 }
 
-func playHandler(gameWorld *world.World, broadcaster *world.Broadcaster) gin.HandlerFunc {
+// Race definitions and preset lineups, clients use them for pickers and sprites
+func racesHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, world.Races())
+}
+
+func playHandler(rooms *RoomManager) gin.HandlerFunc {
     return func(c *gin.Context) {
 		mapName := c.Param("mapName")
-		log.Printf("=== LOADING MAP: %s ===", mapName)
-		
-		gameWorld.Reset()
-		gameWorld.InitMap(mapName)
-		broadcaster.BroadcastGrid()
-		broadcaster.BroadcastStats()
+
+		// Every visit without a room gets its own, so nobody wipes someone else's game
+		roomID := c.Query("room")
+		if roomID == "" {
+			q := c.Request.URL.Query()
+			q.Set("room", NewRoomID())
+			c.Redirect(http.StatusFound, c.Request.URL.Path + "?" + q.Encode())
+			return
+		}
+
+		room, created := rooms.GetOrCreate(roomID)
+
+		// Optional ?seed= replays a reported battle exactly
+		seedStr := c.Query("seed")
+		seed, seedErr := strconv.ParseInt(seedStr, 10, 64)
+		reseed := seedStr != "" && seedErr == nil
+
+		// Optional ?width=&height= picks the grid size, e.g. 50x50 skirmish or 400x200 continent
+		width, height := room.World.Size()
+		if wStr, hStr := c.Query("width"), c.Query("height"); wStr != "" || hStr != "" {
+			if wStr == "" {
+				wStr = strconv.Itoa(world.DefaultGridSize)
+			}
+			if hStr == "" {
+				hStr = strconv.Itoa(world.DefaultGridSize)
+			}
+			width, _ = strconv.Atoi(wStr)
+			height, _ = strconv.Atoi(hStr)
+			if err := world.ValidGridSize(width, height); err != nil {
+				c.String(http.StatusBadRequest, err.Error())
+				return
+			}
+		}
+
+		// Joining an existing room keeps its game, only (re)build when the map, seed or size changes
+		room.mu.Lock()
+		if reseed && seed != room.World.Seed() {
+			room.World.Reseed(seed)
+			room.MapName = "" // Force rebuild with the new seed
+		}
+		if curW, curH := room.World.Size(); curW != width || curH != height {
+			room.World.Resize(width, height) // Already validated
+			room.MapName = ""
+		}
+		if created || room.MapName != mapName {
+			log.Printf("=== LOADING MAP: %s (room %s, seed %d) ===", mapName, roomID, room.World.Seed())
+			room.MapName = mapName
+
+			room.World.Reset()
+			room.World.InitMap(mapName)
+			room.Broadcaster.BroadcastGrid()
+			room.Broadcaster.BroadcastStats()
+		}
+		room.mu.Unlock()
 		
 		// Render the appropriate template based on map
 		switch mapName {
@@ -46,12 +102,12 @@ func playHandler(gameWorld *world.World, broadcaster *world.Broadcaster) gin.Han
 			c.HTML(200, "verticalworld.html", nil)
 		case "northsouth":
 			c.HTML(200, "northsouthworld.html", nil)
-        case "fourquadrants":
-            c.HTML(200, "fourquadsworld.html", nil)
+        case "fourquadrants", "islands":
+            c.HTML(200, "fourquadsworld.html", nil) // Same four tribes, same client
 		case "custommap":
 			c.HTML(200, "customworld.html", nil)
 		default:
 			c.HTML(200, "index.html", nil)
 		}
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Scrimzay/worldboxsim/internal/world"
+)
+
+var snapshotNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// Named world snapshots stored as <dir>/<name>.json
+type SnapshotStore struct {
+	dir string
+}
+
+func NewSnapshotStore(dir string) *SnapshotStore {
+	return &SnapshotStore{dir: dir}
+}
+
+// Names are used as file names, so only allow a safe subset
+func (s *SnapshotStore) path(name string) (string, error) {
+	if !snapshotNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid snapshot name %q (letters, digits, - and _ only, max 64)", name)
+	}
+
+	return filepath.Join(s.dir, name+".json"), nil
+}
+
+func (s *SnapshotStore) Save(name string, w *world.World) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("create snapshot dir: %w", err)
+	}
+
+	// Write to a temp file first so a failed save never clobbers an older snapshot
+	tmp, err := os.CreateTemp(s.dir, name+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := w.Snapshot(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *SnapshotStore) Load(name string, w *world.World) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("no snapshot named %q", name)
+		}
+		return fmt.Errorf("open snapshot: %w", err)
+	}
+	defer f.Close()
+
+	return w.Restore(f)
+}
+
+// Saved snapshot names, sorted
+func (s *SnapshotStore) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	names := []string{}
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
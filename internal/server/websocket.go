@@ -30,11 +30,7 @@ type InspectAction struct {
 
 type PlaceBatchAction struct {
 	Action string `json:"action"`
-	Places []struct {
-		X int `json:"x"`
-		Y int `json:"y"`
-		Type uint8 `json:"type"`
-	} `json:"places"`
+	Places []world.Placement `json:"places"`
 }
 
 type SpeedAction struct {
@@ -42,18 +38,70 @@ type SpeedAction struct {
 	Multiplier float64 `json:"multiplier"`
 }
 
+type MaxFPSAction struct {
+	Action string `json:"action"`
+	FPS int `json:"fps"` // 0 = a frame every tick
+}
+
+type RelationAction struct {
+	Action string `json:"action"`
+	A uint8 `json:"a"` // Tribe IDs
+	B uint8 `json:"b"`
+	Relation string `json:"relation"` // "war", "peace" or "alliance"
+}
+
+type DiplomacyAIAction struct {
+	Action string `json:"action"`
+	Enabled bool `json:"enabled"`
+}
+
+type BuyRanksAction struct {
+	Action string `json:"action"`
+	Enabled bool `json:"enabled"`
+}
+
+type GodPowerAction struct {
+	Action string `json:"action"`
+	Power string `json:"power"` // "meteor", "earthquake", "lightning", "plague" or "fire"
+	X int `json:"x"`
+	Y int `json:"y"`
+	Radius int `json:"radius"` // Up to world.MaxPowerRadius, ignored by lightning
+}
+
 type PauseAction struct {
 	Action string `json:"action"`
 }
 
+type SnapshotAction struct {
+	Action string `json:"action"`
+	Name string `json:"name"`
+}
+
 type CustomMapAction struct {
 	Action string `json:"action"`
+	Width int `json:"width"` // Optional, current size if 0
+	Height int `json:"height"`
 	Terrain []uint8 `json:"terrain"`
 	TribeAssignments map[string]string `json:"tribeAssignments"`
 }
 
-func HandleWebsocket(broadcaster *world.Broadcaster, gameWorld *world.World) gin.HandlerFunc {
+// Marshal v and write it to one client under its write lock
+func sendJSON(broadcaster *world.Broadcaster, conn *websocket.Conn, v interface{}) {
+	if err := broadcaster.WriteJSON(conn, v); err != nil {
+		log.Println("Response send error:", err)
+	}
+}
+
+func HandleWebsocket(rooms *RoomManager, snapshots *SnapshotStore) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		room, ok := rooms.Get(c.Query("room"))
+		if !ok {
+			c.String(http.StatusNotFound, "unknown room")
+			return
+		}
+		broadcaster := room.Broadcaster
+		gameWorld := room.World
+
 		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 		if err != nil {
 			log.Println("WS upgrade error:", err)
@@ -97,17 +145,15 @@ func HandleWebsocket(broadcaster *world.Broadcaster, gameWorld *world.World) gin
 						broadcaster.SetSpeed(speed.Multiplier)
 					}
 
+				case "set_max_fps":
+					var fps MaxFPSAction
+					json.Unmarshal(msg, &fps)
+					broadcaster.SetMaxFPS(fps.FPS)
+
 				case "place_batch":
 					var batch PlaceBatchAction
 					json.Unmarshal(msg, &batch)
-					placed := false
-					for _, p := range batch.Places {
-						if gameWorld.PlaceEntity(p.X, p.Y, p.Type) {
-							placed = true
-							fmt.Println("placed entity 2")
-						}
-					}
-					if placed {
+					if gameWorld.PlaceBatch(batch.Places) {
 						broadcaster.BroadcastGrid()
 						broadcaster.BroadcastStats()
 					}
@@ -131,7 +177,8 @@ func HandleWebsocket(broadcaster *world.Broadcaster, gameWorld *world.World) gin
 					}
 
 					// Bounds check
-					if inspect.X < 0 || inspect.X >= world.GridSize || inspect.Y < 0 || inspect.Y >= world.GridSize {
+					width, height := gameWorld.Size()
+					if inspect.X < 0 || inspect.X >= width || inspect.Y < 0 || inspect.Y >= height {
 						continue
 					}
 
@@ -155,26 +202,34 @@ func HandleWebsocket(broadcaster *world.Broadcaster, gameWorld *world.World) gin
 						gameWorld.Mu.RUnlock()
 
 						weaponStr := "None"
-						damageBonus := 0
-						if ent.Weapon == world.WeaponWood {
+						switch ent.Weapon {
+						case world.WeaponWood:
 							weaponStr = "Wood Sword"
-							damageBonus = 3
-						} else if ent.Weapon == world.WeaponStone {
+						case world.WeaponStone:
 							weaponStr = "Stone Sword"
-							damageBonus = 4
+						case world.WeaponBow:
+							weaponStr = "Bow"
+						case world.WeaponIron:
+							weaponStr = "Iron Sword"
+						case world.WeaponSteel:
+							weaponStr = "Steel Sword"
 						}
+						damageBonus := ent.Weapon.Bonus()
 
 						armorStr := "None"
-						defenseBonus := 0
-						if ent.Armor == world.ArmorWood {
+						switch ent.Armor {
+						case world.ArmorWood:
 							armorStr = "Wood Armor"
-							defenseBonus = 2
-						} else if ent.Armor == world.ArmorStone {
+						case world.ArmorStone:
 							armorStr = "Stone Armor"
-							defenseBonus = 3
+						case world.ArmorIron:
+							armorStr = "Iron Armor"
+						case world.ArmorSteel:
+							armorStr = "Steel Armor"
 						}
+						defenseBonus := ent.Armor.Defensebonus()
 
-						// Calculate total damage including racial passive + rank
+						// Racial passive + rank, totals straight from the combat math
 						racialDamageBonus := 0
 						racialDefenseBonus := 0
 						if ok {
@@ -183,15 +238,27 @@ func HandleWebsocket(broadcaster *world.Broadcaster, gameWorld *world.World) gin
 						}
 						rankDamageBonus := ent.Rank.DamageBonus()
 						rankArmorBonus := ent.Rank.ArmorBonus()
-						totalDamage := 5 + damageBonus + racialDamageBonus + rankDamageBonus
-						totalDefense := defenseBonus + rankArmorBonus + racialDefenseBonus
+						gameWorld.Mu.RLock()
+						totalDamage := ent.TotalDamage(gameWorld)
+						totalDefense := ent.TotalArmor(gameWorld)
+						gameWorld.Mu.RUnlock()
 
 						// Format evasion as percentage
 						evasionPercent := int(ent.Evasion * 100)
 
-						resp["name"] = fmt.Sprintf("%s Entity #%d", tribeName, ent.ID)
+						kind := "Entity"
+						if ent.Leader {
+							kind = "Leader"
+						}
+						resp["name"] = fmt.Sprintf("%s %s #%d", tribeName, kind, ent.ID)
 						resp["health"] = ent.Health
+						resp["morale"] = ent.Morale
+						resp["routed"] = ent.Routed()
+						resp["age"] = gameWorld.EntityAge(ent)
+						resp["lifespan"] = cfg.Lifespan
 						resp["weapon"] = weaponStr
+						resp["weaponDamage"] = damageBonus
+						resp["range"] = ent.Weapon.Range()
 						resp["armor"] = armorStr
 						resp["damage"] = totalDamage
 						resp["defense"] = totalDefense
@@ -199,6 +266,8 @@ func HandleWebsocket(broadcaster *world.Broadcaster, gameWorld *world.World) gin
 						resp["racialDamage"] = racialDamageBonus
 						resp["racialDefense"] = racialDefenseBonus
 						resp["rank"] = ent.Rank.String()
+						resp["kills"] = ent.Kills
+						resp["leader"] = ent.Leader
 						resp["rankDamage"] = rankDamageBonus
 						resp["rankArmor"] = rankArmorBonus
 					
@@ -206,29 +275,28 @@ func HandleWebsocket(broadcaster *world.Broadcaster, gameWorld *world.World) gin
     					racialDefenseBonus, totalDefense, defenseBonus, rankArmorBonus)
 					}
 
-					data, err := json.Marshal(resp)
-					if err != nil {
-						log.Println("Inspect response marshal error:", err)
-						continue
-					}
-
 					// Use the per-connection write lock
-					if mu, ok := broadcaster.WriteMu[conn]; ok {
-						mu.Lock()
-						if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
-							log.Println("Inspect send error:", err)
-							mu.Unlock()
-							broadcaster.Unregister(conn)
-							break
-						}
-						mu.Unlock()
+					if err := broadcaster.WriteJSON(conn, resp); err != nil {
+						log.Println("Inspect send error:", err)
+						broadcaster.Unregister(conn)
+						break
 					}
 				
 				case "init_custom_map":
 					var customMap CustomMapAction
 					json.Unmarshal(msg, &customMap)
 
-					success := gameWorld.InitCustomMap(customMap.Terrain, customMap.TribeAssignments)
+					// Builder may have painted a different size than the room currently has
+					success := true
+					width, height := gameWorld.Size()
+					if customMap.Width > 0 && customMap.Height > 0 && (customMap.Width != width || customMap.Height != height) {
+						if err := gameWorld.Resize(customMap.Width, customMap.Height); err != nil {
+							log.Println("Custom map resize error:", err)
+							success = false
+						}
+					}
+
+					success = success && gameWorld.InitCustomMap(customMap.Terrain, customMap.TribeAssignments)
 					
 					if success {
 						broadcaster.BroadcastGrid()
@@ -242,6 +310,7 @@ func HandleWebsocket(broadcaster *world.Broadcaster, gameWorld *world.World) gin
 								"name":          cfg.Name,
 								"entityVizCode": cfg.EntityVizCode,
 								"homeTerrain":   cfg.HomeTerrain,
+								"sprites":       cfg.Sprites,
 							}
 						}
 						gameWorld.Mu.RUnlock()
@@ -251,27 +320,110 @@ func HandleWebsocket(broadcaster *world.Broadcaster, gameWorld *world.World) gin
 							"action": "custom_map_initialized",
 							"tribes": tribeInfo,
 						}
-						confirmData, _ := json.Marshal(confirmMsg)
-						
-						if mu, ok := broadcaster.WriteMu[conn]; ok {
-							mu.Lock()
-							conn.WriteMessage(websocket.TextMessage, confirmData)
-							mu.Unlock()
-						}
+						broadcaster.WriteJSON(conn, confirmMsg)
 					} else {
 						// Send error back
 						errMsg := map[string]string{"action": "custom_map_error", "error": "Failed to initialize map"}
-						errData, _ := json.Marshal(errMsg)
-						
-						if mu, ok := broadcaster.WriteMu[conn]; ok {
-							mu.Lock()
-							conn.WriteMessage(websocket.TextMessage, errData)
-							mu.Unlock()
-						}
+						broadcaster.WriteJSON(conn, errMsg)
+					}
+
+				case "set_relation":
+					var rel RelationAction
+					json.Unmarshal(msg, &rel)
+
+					resp := map[string]interface{}{"action": "set_relation_response", "ok": true}
+					relation, ok := world.ParseRelation(rel.Relation)
+					var err error
+					if !ok {
+						err = fmt.Errorf("unknown relation %q (want war, peace or alliance)", rel.Relation)
+					} else {
+						err = gameWorld.SetRelation(rel.A, rel.B, relation)
+					}
+					if err != nil {
+						resp["ok"] = false
+						resp["error"] = err.Error()
+					} else {
+						broadcaster.BroadcastStats()
 					}
+					sendJSON(broadcaster, conn, resp)
+
+				case "set_diplomacy_ai":
+					var ai DiplomacyAIAction
+					json.Unmarshal(msg, &ai)
+					gameWorld.SetDiplomacyAI(ai.Enabled)
+					broadcaster.BroadcastStats()
+
+				case "set_buy_ranks":
+					var buy BuyRanksAction
+					json.Unmarshal(msg, &buy)
+					gameWorld.SetBuyRanks(buy.Enabled)
+					broadcaster.BroadcastStats()
+
+				case "god_power":
+					var gp GodPowerAction
+					json.Unmarshal(msg, &gp)
+
+					resp := map[string]interface{}{"action": "god_power_response", "ok": true}
+					power, ok := world.ParseGodPower(gp.Power)
+					var event world.PowerEvent
+					var err error
+					if !ok {
+						err = fmt.Errorf("unknown god power %q (want meteor, earthquake, lightning, plague or fire)", gp.Power)
+					} else {
+						event, err = gameWorld.UseGodPower(power, gp.X, gp.Y, gp.Radius)
+					}
+					if err != nil {
+						resp["ok"] = false
+						resp["error"] = err.Error()
+					} else {
+						broadcaster.BroadcastEvent(event)
+						broadcaster.BroadcastGrid()
+						broadcaster.BroadcastStats()
+					}
+					sendJSON(broadcaster, conn, resp)
 
 				case "toggle_pause":
 					broadcaster.TogglePause()
+
+				case "save_world":
+					var save SnapshotAction
+					json.Unmarshal(msg, &save)
+
+					resp := map[string]interface{}{"action": "save_world_response", "name": save.Name, "ok": true}
+					if err := snapshots.Save(save.Name, gameWorld); err != nil {
+						log.Printf("Save world %q error: %v", save.Name, err)
+						resp["ok"] = false
+						resp["error"] = err.Error()
+					} else {
+						log.Printf("Saved world %q (room %s)", save.Name, room.ID)
+					}
+					sendJSON(broadcaster, conn, resp)
+
+				case "load_world":
+					var load SnapshotAction
+					json.Unmarshal(msg, &load)
+
+					resp := map[string]interface{}{"action": "load_world_response", "name": load.Name, "ok": true}
+					if err := snapshots.Load(load.Name, gameWorld); err != nil {
+						log.Printf("Load world %q error: %v", load.Name, err)
+						resp["ok"] = false
+						resp["error"] = err.Error()
+					} else {
+						log.Printf("Loaded world %q (room %s)", load.Name, room.ID)
+						broadcaster.BroadcastGrid()
+						broadcaster.BroadcastStats()
+					}
+					sendJSON(broadcaster, conn, resp)
+
+				case "list_worlds":
+					resp := map[string]interface{}{"action": "list_worlds_response"}
+					names, err := snapshots.List()
+					if err != nil {
+						log.Println("List worlds error:", err)
+						resp["error"] = err.Error()
+					}
+					resp["names"] = names
+					sendJSON(broadcaster, conn, resp)
 				}
 			}
 		}
@@ -0,0 +1,104 @@
+package world
+
+// Aging. Every unit remembers the tick it was born, its race sets how long it
+// lives (TribeConfig.Lifespan, 0 = never ages). From OldAgePercent of the
+// lifespan its health is capped lower and lower, down to 0 at the end
+const (
+	DefaultLifespan = 6000 // Ticks, for races that don't set one
+	OldAgePercent   = 80   // Decline starts at this share of the lifespan
+)
+
+// Ticks since ent was born, caller holds Mu
+func (w *World) age(ent *Entity) int64 {
+	return w.tickCount - ent.Born
+}
+
+// Highest health ent can have at its age, caller holds Mu
+func (w *World) healthCap(ent *Entity) int {
+	lifespan := w.Tribes[ent.Tribe].Lifespan
+	if lifespan <= 0 {
+		return ent.MaxHealth()
+	}
+
+	age := w.age(ent)
+	declineStart := lifespan * OldAgePercent / 100
+	if age < declineStart {
+		return ent.MaxHealth()
+	}
+	if age >= lifespan {
+		return 0
+	}
+
+	return int(int64(ent.MaxHealth()) * (lifespan - age) / (lifespan - declineStart))
+}
+
+// Birth tick for a starter. Starters get a random head start of up to a
+// quarter of their lifespan so the first generation doesn't die out in one tick
+func (w *World) starterBorn(cfg TribeConfig) int64 {
+	if cfg.Lifespan < 4 {
+		return w.tickCount
+	}
+
+	return w.tickCount - w.rng.Int63n(cfg.Lifespan/4)
+}
+
+// Caps ent's health for its age, true if that killed it. caller holds Mu
+func (w *World) ageEntity(ent *Entity) bool {
+	if limit := w.healthCap(ent); ent.Health > limit {
+		ent.Health = limit
+	}
+
+	return ent.Health <= 0
+}
+
+// End-of-tick old age for ents and the units aboard boats, returns deaths
+// with those it took. caller holds Mu
+func (w *World) ageEntities(ents [][]*Entity, deaths []death) []death {
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			ent := ents[y][x]
+			if ent == nil {
+				continue
+			}
+
+			if w.ageEntity(ent) {
+				deaths = append(deaths, death{x, y, ent.Tribe, ent.Leader})
+				ents[y][x] = nil
+				w.lastReprodTick[y][x] = 0
+			}
+		}
+	}
+
+	return w.cullPassengers(deaths, w.ageEntity)
+}
+
+// Mean age of each tribe's living units, in ticks
+func (w *World) AverageAgeByTribe() map[uint8]float64 {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+
+	sums := make(map[uint8]int64)
+	counts := make(map[uint8]int64)
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if ent := w.Entities[y][x]; ent != nil {
+				sums[ent.Tribe] += w.age(ent)
+				counts[ent.Tribe]++
+			}
+		}
+	}
+
+	avg := make(map[uint8]float64)
+	for tribe, n := range counts {
+		avg[tribe] = float64(sums[tribe]) / float64(n)
+	}
+
+	return avg
+}
+
+// Age of ent in ticks
+func (w *World) EntityAge(ent *Entity) int64 {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+	return w.age(ent)
+}
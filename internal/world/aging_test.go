@@ -0,0 +1,101 @@
+package world
+
+import "testing"
+
+func agingWorld(t *testing.T, lifespan int64) (*World, *Entity) {
+	t.Helper()
+
+	w := newTestWorld(t, 1, "r", "1")
+	cfg := w.Tribes[1]
+	cfg.Lifespan = lifespan
+	w.Tribes[1] = cfg
+	w.EntityStats.MoveChance = 0
+	w.resources[1] = &TribeResources{Food: 1000}
+	return w, w.Entities[0][0]
+}
+
+func TestHealthDeclinesInOldAge(t *testing.T) {
+	w, ent := agingWorld(t, 100)
+
+	w.tickCount = 79 // Next tick is the start of the decline
+	w.PreWarUpdate()
+	if ent.Health != 100 {
+		t.Fatalf("health %d at the start of old age, want 100", ent.Health)
+	}
+
+	w.tickCount = 89
+	w.PreWarUpdate()
+	if ent.Health != 50 {
+		t.Fatalf("health %d halfway through old age, want 50", ent.Health)
+	}
+}
+
+func TestDiesAtEndOfLifespan(t *testing.T) {
+	w, _ := agingWorld(t, 100)
+
+	w.tickCount = 99
+	w.PreWarUpdate()
+	if w.Entities[0][0] != nil {
+		t.Fatal("unit outlived its lifespan")
+	}
+}
+
+func TestNoLifespanNeverAges(t *testing.T) {
+	w, ent := agingWorld(t, 0)
+
+	w.tickCount = 1000000
+	w.PreWarUpdate()
+	if w.Entities[0][0] != ent || ent.Health != 100 {
+		t.Fatal("unit without a lifespan aged")
+	}
+}
+
+func TestAverageAge(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr", "11.")
+	w.Entities[0][0].Born = -10
+	w.Entities[0][1].Born = 4
+	w.tickCount = 20
+
+	if got := w.AverageAgeByTribe()[1]; got != 23 {
+		t.Fatalf("average age %.1f, want 23", got)
+	}
+}
+
+func TestLeaderDyingOfOldAgeIsMourned(t *testing.T) {
+	w := newTestWorld(t, 1, "rrrrrrrrrr", "11.......1")
+	cfg := w.Tribes[1]
+	cfg.Lifespan = 100
+	w.Tribes[1] = cfg
+	w.EntityStats.MoveChance = 0
+	w.resources[1] = &TribeResources{Food: 1000}
+
+	w.tickCount = 99
+	leader, near, far := w.Entities[0][0], w.Entities[0][1], w.Entities[0][9]
+	leader.Leader = true
+	leader.Born = 0
+	near.Born, far.Born = w.tickCount, w.tickCount
+
+	w.PreWarUpdate()
+	if w.Entities[0][0] != nil {
+		t.Fatal("leader outlived its lifespan")
+	}
+	if fell, ok := w.leaderFell[1]; !ok || fell != 100 {
+		t.Fatalf("leaderFell %d (set %v), want the cooldown started at 100", fell, ok)
+	}
+	if got, want := far.Morale, MaxMorale-LeaderDeathShock; got != want {
+		t.Fatalf("far unit morale %d, want %d", got, want)
+	}
+	// Peace recovery on home rock softens the DeathShock, not the mourning
+	shocked := MaxMorale + MoraleRecovery + HomeMoraleRecovery - DeathShock
+	if got, want := near.Morale, shocked-LeaderDeathShock; got != want {
+		t.Fatalf("unit beside the leader has morale %d, want %d", got, want)
+	}
+
+	// The mourning passes before the war, nobody marches out routed
+	for i := 0; i < 10; i++ {
+		w.PreWarUpdate()
+	}
+	if near.Morale != MaxMorale || far.Morale != MaxMorale {
+		t.Fatalf("morale %d and %d after a quiet peace, want %d", near.Morale, far.Morale, MaxMorale)
+	}
+}
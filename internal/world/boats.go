@@ -0,0 +1,357 @@
+package world
+
+// Boats. A tribe builds them from wood on water next to its units, up to
+// MaxBoats: in peace whenever it isn't saving, in war only next to units that
+// have no land route to an enemy. In war those stranded units board an
+// adjacent boat, which sails them to the nearest shore they can fight from
+// and puts them ashore, then goes back for more. Boats only sail on
+// TerrainWater, can't be attacked, and their passengers count as alive
+// unless the boat is adrift with no shore left to reach
+const (
+	BoatVizBase  uint8 = 40 // Grid code sent to clients is this plus the tribe, see BoatVizCode
+	BoatCapacity       = 4
+	MaxBoats           = 2 // Per tribe
+)
+
+var BoatCost = Cost{Wood: 3}
+
+// Grid code for a boat of tribe, one per tribe like leaders
+func BoatVizCode(tribe uint8) uint8 {
+	return BoatVizBase + tribe
+}
+
+type Boat struct {
+	Tribe      uint8
+	Passengers []*Entity
+}
+
+func newBoatLayer(width, height int) [][]*Boat {
+	layer := make([][]*Boat, height)
+	for i := range layer {
+		layer[i] = make([]*Boat, width)
+	}
+
+	return layer
+}
+
+// Cost of sailing onto t, 0 = can't
+func seaCost(t TerrainType) int32 {
+	if t == TerrainWater {
+		return 1
+	}
+
+	return 0
+}
+
+// True if ent is at war but can't walk to any enemy from (x, y), caller holds Mu
+func (w *World) stranded(ent *Entity, x, y int) bool {
+	f := w.attackField(ent.Tribe)
+	return f != nil && !ent.Routed() && f[y*w.Width+x] == unreachable
+}
+
+// Calls fn for every unit aboard a boat, caller holds Mu
+func (w *World) eachPassenger(fn func(ent *Entity)) {
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if b := w.boats[y][x]; b != nil {
+				for _, ent := range b.Passengers {
+					fn(ent)
+				}
+			}
+		}
+	}
+}
+
+// Drops the units aboard boats that dies says are dead, returns deaths with
+// them, recorded at their boat's cell. Passengers age and eat like everyone
+// else. caller holds Mu
+func (w *World) cullPassengers(deaths []death, dies func(ent *Entity) bool) []death {
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			b := w.boats[y][x]
+			if b == nil {
+				continue
+			}
+
+			aboard := b.Passengers[:0]
+			for _, ent := range b.Passengers {
+				if dies(ent) {
+					deaths = append(deaths, death{x, y, ent.Tribe, ent.Leader})
+				} else {
+					aboard = append(aboard, ent)
+				}
+			}
+			b.Passengers = aboard
+		}
+	}
+
+	return deaths
+}
+
+// Adds the units aboard boats to counts, except on boats adrift: their
+// passengers can never fight again and would keep a lost war going. caller
+// holds Mu
+func (w *World) countPassengers(counts map[uint8]int) {
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if b := w.boats[y][x]; b != nil && !w.adrift(b, x, y) {
+				counts[b.Tribe] += len(b.Passengers)
+			}
+		}
+	}
+}
+
+// True if the boat at (x, y) can't sail to any shore its tribe can fight
+// from. Only reads the landing field sailBoats already built, so it's safe
+// under RLock and a boat counts until that's happened. caller holds Mu
+func (w *World) adrift(b *Boat, x, y int) bool {
+	f, ok := w.landingFields[b.Tribe]
+	return ok && f[y*w.Width+x] == unreachable
+}
+
+// Sea field to the water off every shore the tribe can fight from: cells next
+// to land its attack field reaches. Built when first asked for, caller holds Mu
+func (w *World) landingField(tribe uint8) flowField {
+	if f, ok := w.landingFields[tribe]; ok {
+		return f
+	}
+
+	attack := w.attackField(tribe)
+	var goals []int
+	if attack != nil {
+		goals = w.shoreCells(func(x, y int) bool {
+			return attack[y*w.Width+x] != unreachable
+		})
+	}
+	f := w.buildSeaField(goals)
+	if w.landingFields == nil {
+		w.landingFields = make(map[uint8]flowField)
+	}
+	w.landingFields[tribe] = f
+
+	return f
+}
+
+// Sea field to the water next to the tribe's stranded units, empty boats
+// follow it back to pick them up. caller holds Mu
+func (w *World) dockField(tribe uint8) flowField {
+	if f, ok := w.dockFields[tribe]; ok {
+		return f
+	}
+
+	goals := w.shoreCells(func(x, y int) bool {
+		ent := w.Entities[y][x]
+		return ent != nil && ent.Tribe == tribe && w.stranded(ent, x, y)
+	})
+	f := w.buildSeaField(goals)
+	if w.dockFields == nil {
+		w.dockFields = make(map[uint8]flowField)
+	}
+	w.dockFields[tribe] = f
+
+	return f
+}
+
+// Land field to the shore next to the tribe's boats, stranded units follow it
+// to go aboard. caller holds Mu
+func (w *World) embarkField(tribe uint8) flowField {
+	if f, ok := w.embarkFields[tribe]; ok {
+		return f
+	}
+
+	var goals []int
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if b := w.boats[y][x]; b == nil || b.Tribe != tribe {
+				continue
+			}
+			for _, dir := range [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+				nx, ny := x+dir[0], y+dir[1]
+				if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+					goals = append(goals, ny*w.Width+nx)
+				}
+			}
+		}
+	}
+	f := w.buildFlowField(goals)
+	if w.embarkFields == nil {
+		w.embarkFields = make(map[uint8]flowField)
+	}
+	w.embarkFields[tribe] = f
+
+	return f
+}
+
+// Water cells with a land neighbour that passes ok, caller holds Mu
+func (w *World) shoreCells(ok func(x, y int) bool) []int {
+	var cells []int
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if TerrainType(w.Terrain[y*w.Width+x]) != TerrainWater {
+				continue
+			}
+			for _, dir := range [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+				nx, ny := x+dir[0], y+dir[1]
+				if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height && IsPassable(TerrainType(w.Terrain[ny*w.Width+nx])) && ok(nx, ny) {
+					cells = append(cells, y*w.Width+x)
+					break
+				}
+			}
+		}
+	}
+
+	return cells
+}
+
+// Builds boats for tribes that can pay, on a random free water cell next to
+// one of their units. In war (saving is nil) only next to stranded units.
+// caller holds Mu
+func (w *World) buildBoats(ents [][]*Entity, saving map[uint8]bool) {
+	war := saving == nil
+	owned := make(map[uint8]int)
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if b := w.boats[y][x]; b != nil {
+				owned[b.Tribe]++
+			}
+		}
+	}
+
+	for _, tribe := range w.sortedTribeIDs() {
+		res := w.resources[tribe]
+		if saving[tribe] || owned[tribe] >= MaxBoats || res == nil || !res.canPay(BoatCost) {
+			continue
+		}
+
+		var sites []int
+		for _, i := range w.shoreCells(func(x, y int) bool {
+			ent := ents[y][x]
+			return ent != nil && ent.Tribe == tribe && (!war || w.stranded(ent, x, y))
+		}) {
+			if w.boats[i/w.Width][i%w.Width] == nil {
+				sites = append(sites, i)
+			}
+		}
+		if len(sites) == 0 {
+			continue
+		}
+
+		i := sites[w.rng.Intn(len(sites))]
+		res.pay(BoatCost)
+		w.boats[i/w.Width][i%w.Width] = &Boat{Tribe: tribe}
+	}
+}
+
+// War step for every boat: put passengers ashore if it's off a shore they can
+// fight from, take stranded units aboard, then sail a cell. A boat sets off
+// once it's full or nobody boarded this tick. caller holds Mu
+func (w *World) sailBoats(ents [][]*Entity) {
+	type pos struct{ x, y int }
+	var fleet []pos
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if w.boats[y][x] != nil {
+				fleet = append(fleet, pos{x, y})
+			}
+		}
+	}
+
+	for _, p := range fleet {
+		b := w.boats[p.y][p.x]
+		landing := w.landingField(b.Tribe)
+		if landing[p.y*w.Width+p.x] == unreachable {
+			continue // Nowhere to fight from by sea
+		}
+
+		if landing[p.y*w.Width+p.x] == 0 {
+			w.landPassengers(ents, b, p.x, p.y)
+		}
+		boarded := w.boardBoat(ents, b, p.x, p.y)
+
+		f := w.dockField(b.Tribe)
+		if len(b.Passengers) > 0 {
+			if len(b.Passengers) < BoatCapacity && boarded {
+				continue // Waiting for the rest
+			}
+			f = landing
+		}
+
+		if nx, ny := w.sailStep(f, p.x, p.y); nx != p.x || ny != p.y {
+			w.boats[ny][nx] = b
+			w.boats[p.y][p.x] = nil
+		}
+	}
+}
+
+// Moves passengers onto free land next to (x, y) that leads to an enemy,
+// those that don't fit stay aboard. caller holds Mu
+func (w *World) landPassengers(ents [][]*Entity, b *Boat, x, y int) {
+	attack := w.attackField(b.Tribe)
+	if attack == nil {
+		return // Not at war, nowhere to fight
+	}
+	for _, dir := range [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+		if len(b.Passengers) == 0 {
+			return
+		}
+		nx, ny := x+dir[0], y+dir[1]
+		if nx < 0 || nx >= w.Width || ny < 0 || ny >= w.Height || ents[ny][nx] != nil {
+			continue
+		}
+		if !IsPassable(TerrainType(w.Terrain[ny*w.Width+nx])) || attack[ny*w.Width+nx] == unreachable {
+			continue
+		}
+
+		ents[ny][nx] = b.Passengers[0]
+		w.lastReprodTick[ny][nx] = 0
+		b.Passengers = b.Passengers[1:]
+	}
+}
+
+// Takes stranded units of the boat's tribe next to (x, y) aboard while
+// there's room, true if any did. caller holds Mu
+func (w *World) boardBoat(ents [][]*Entity, b *Boat, x, y int) bool {
+	boarded := false
+	for _, dir := range [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+		if len(b.Passengers) >= BoatCapacity {
+			break
+		}
+		nx, ny := x+dir[0], y+dir[1]
+		if nx < 0 || nx >= w.Width || ny < 0 || ny >= w.Height {
+			continue
+		}
+		ent := ents[ny][nx]
+		if ent == nil || ent.Tribe != b.Tribe || !w.stranded(ent, nx, ny) {
+			continue
+		}
+
+		b.Passengers = append(b.Passengers, ent)
+		ents[ny][nx] = nil
+		w.lastReprodTick[ny][nx] = 0
+		boarded = true
+	}
+
+	return boarded
+}
+
+// Next cell down f from (x, y) on free water, (x, y) itself if there's none
+// or it's already at a goal. caller holds Mu
+func (w *World) sailStep(f flowField, x, y int) (int, int) {
+	best := f[y*w.Width+x]
+	if best == 0 || best == unreachable {
+		return x, y
+	}
+
+	bx, by := x, y
+	for _, dir := range [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+		nx, ny := x+dir[0], y+dir[1]
+		if nx < 0 || nx >= w.Width || ny < 0 || ny >= w.Height || w.boats[ny][nx] != nil {
+			continue
+		}
+		if j := ny*w.Width + nx; f[j] < best {
+			best, bx, by = f[j], nx, ny
+		}
+	}
+
+	return bx, by
+}
@@ -0,0 +1,202 @@
+package world
+
+import "testing"
+
+func TestFordCrossesWater(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rr~bb
+rr=bb
+rr~bb
+`, "")
+	f := w.buildFlowField([]int{4})
+
+	if f[2] != unreachable {
+		t.Fatal("water is walkable")
+	}
+	if f[0] == unreachable {
+		t.Fatal("no way across the ford")
+	}
+}
+
+func TestPeaceBuildsBoatByTheWater(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rr~~~
+`, `
+.1...
+`)
+	w.resources[1] = &TribeResources{Wood: BoatCost.Wood + 1}
+
+	w.buildBoats(w.Entities, map[uint8]bool{})
+	if b := w.boats[0][2]; b == nil || b.Tribe != 1 {
+		t.Fatal("no boat next to the unit")
+	}
+	if got := w.resources[1].Wood; got != 1 {
+		t.Fatalf("%d wood left, want 1", got)
+	}
+
+	w.buildBoats(w.Entities, map[uint8]bool{})
+	if got := w.resources[1].Wood; got != 1 {
+		t.Fatal("built a second boat without the wood")
+	}
+}
+
+func TestBoatFerriesStrandedUnit(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rr~~~bb
+rr~~~bb
+`, `
+.1....2
+.......
+`)
+	w.EntityStats.MoveChance = 0
+	ent := w.Entities[0][1]
+	w.boats[0][2] = &Boat{Tribe: 1}
+	w.StartWar()
+
+	w.Update()
+	if w.Entities[0][1] != nil || len(w.boats[0][2].Passengers) != 1 {
+		t.Fatal("stranded unit didn't board")
+	}
+	if counts := w.CountEntitiesByTribe(); counts[1] != 1 || w.IsGameOver() {
+		t.Fatalf("passenger not counted as alive: %v", counts)
+	}
+
+	for i := 0; i < 10; i++ {
+		w.Update()
+	}
+	if pos, ok := entityPositions(w)[ent]; !ok || pos[0] < 5 {
+		t.Fatalf("unit at %v, want landed on the blue island", pos)
+	}
+}
+
+// Once no shore is left to land on, the passengers can't keep the war going
+func TestAdriftPassengersDontCount(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rr~~~~~~~~~~bb
+`, `
+.............2
+`)
+	w.EntityStats.MoveChance = 0
+	w.boats[0][2] = &Boat{Tribe: 1, Passengers: []*Entity{{Health: 100, Tribe: 1, ID: 1, Rank: RankBase, Morale: MaxMorale}}}
+	w.StartWar()
+
+	w.Update()
+	if w.IsGameOver() {
+		t.Fatal("war over while the boat could still land")
+	}
+
+	// The channel to the blue shore closes, the boat is left in a lake
+	w.Terrain[8] = uint8(TerrainFord)
+	w.dropFlowFields()
+
+	w.Update()
+	if !w.IsGameOver() || w.winner != "2" {
+		t.Fatalf("game over %v, winner %q, want blue once the boat is adrift", w.IsGameOver(), w.winner)
+	}
+}
+
+// A god power drops the fields mid-war, boats still find their way before the
+// next refresh
+func TestLandingAfterFieldsDropped(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rr~bb
+`, `
+....2
+`)
+	ent := &Entity{Health: 100, Tribe: 1, ID: 1, Rank: RankBase, Morale: MaxMorale}
+	b := &Boat{Tribe: 1, Passengers: []*Entity{ent}}
+	w.boats[0][2] = b
+	w.StartWar()
+	w.dropFlowFields()
+
+	if f := w.landingField(1); f[2] != 0 {
+		t.Fatalf("landing field at the boat %d, want 0", f[2])
+	}
+	w.landPassengers(w.Entities, b, 2, 0)
+	if w.Entities[0][3] != ent || len(b.Passengers) != 0 {
+		t.Fatal("passenger not put ashore next to blue")
+	}
+}
+
+func TestIslandsMap(t *testing.T) {
+	for seed := int64(1); seed <= 3; seed++ {
+		w := newMapWorld(t, seed, "islands", 60, 60)
+		if w.CountTerrain(uint8(TerrainWater)) == 0 || w.CountTerrain(uint8(TerrainFord)) == 0 {
+			t.Fatalf("seed %d: no water or fords", seed)
+		}
+		counts := w.CountEntitiesByTribe()
+		for _, tribe := range w.sortedTribeIDs() {
+			if counts[tribe] == 0 {
+				t.Fatalf("seed %d: tribe %d has no starters", seed, tribe)
+			}
+		}
+
+		// Red walks to Blue over the ford, Yellow only by boat
+		var blue []int
+		for i, c := range w.Terrain {
+			if TerrainType(c) == TerrainBlue {
+				blue = append(blue, i)
+			}
+		}
+		f := w.buildFlowField(blue)
+		red := (w.Height/4)*w.Width + w.Width/4
+		yellow := (w.Height*3/4)*w.Width + w.Width/4
+		if f[red] == unreachable || f[yellow] != unreachable {
+			t.Fatalf("seed %d: red reaches blue %v, yellow reaches blue %v", seed, f[red] != unreachable, f[yellow] != unreachable)
+		}
+	}
+}
+
+func TestPassengersGrowOld(t *testing.T) {
+	w := newTestWorld(t, 1, "~~", "")
+	cfg := w.Tribes[1]
+	cfg.Lifespan = 100
+	w.Tribes[1] = cfg
+	w.tickCount = 100
+	old := &Entity{Health: 100, Tribe: 1, ID: 1, Rank: RankBase, Leader: true}
+	young := &Entity{Health: 100, Tribe: 1, ID: 2, Rank: RankBase, Born: 100}
+	w.boats[0][1] = &Boat{Tribe: 1, Passengers: []*Entity{old, young}}
+
+	deaths := w.ageEntities(w.Entities, nil)
+	if b := w.boats[0][1]; len(b.Passengers) != 1 || b.Passengers[0] != young {
+		t.Fatalf("%d aboard after old age, want only the young unit", len(b.Passengers))
+	}
+	if len(deaths) != 1 || deaths[0] != (death{1, 0, 1, true}) {
+		t.Fatalf("deaths %+v, want the leader at the boat", deaths)
+	}
+}
+
+func TestPassengersEat(t *testing.T) {
+	w := newTestWorld(t, 1, "~~", "")
+	w.tickCount = MealTicks
+	ent := &Entity{Health: 100, Tribe: 1, ID: 1, Rank: RankBase}
+	w.boats[0][0] = &Boat{Tribe: 1, Passengers: []*Entity{ent}}
+
+	w.resources[1] = &TribeResources{Food: FoodPerEntity + 1}
+	w.eatMeals(w.Entities, nil, false)
+	if got := w.resources[1].Food; got != 1 {
+		t.Fatalf("food %d after feeding a passenger, want 1", got)
+	}
+
+	w.resources[1].Food = 0
+	ent.Health = StarvationDamage
+	deaths := w.eatMeals(w.Entities, nil, false)
+	if len(w.boats[0][0].Passengers) != 0 || len(deaths) != 1 {
+		t.Fatal("passenger didn't starve with an empty larder")
+	}
+}
+
+func TestSnapshotKeepsBoats(t *testing.T) {
+	w := newMapWorld(t, 7, "islands", 40, 40)
+	w.boats[0][0] = &Boat{Tribe: 2, Passengers: []*Entity{{Health: 42, Tribe: 2, ID: 99, Rank: RankBase}}}
+
+	restored := roundTrip(t, w)
+
+	b := restored.boats[0][0]
+	if b == nil || b.Tribe != 2 || len(b.Passengers) != 1 || b.Passengers[0].Health != 42 {
+		t.Fatalf("boat after restore %+v", b)
+	}
+	if grid := restored.GetGridCopy(); grid[0] != BoatVizCode(2) {
+		t.Fatalf("boat cell renders as %d", grid[0])
+	}
+}
@@ -15,6 +15,21 @@ type Client struct {
 	conn *websocket.Conn
 }
 
+// Keyframe at least this often so a client can never drift for long
+const keyframeEvery = 50 // ~12s at 1x, ~2.5s at the default fps cap
+
+// Default cap on tick frames per second, faster sims skip ticks to stay under it
+const DefaultMaxFPS = 20
+
+// Last grid a client was sent, deltas are computed against it.
+// Guarded by that client's WriteMu
+type clientGrid struct {
+	cells []uint8
+	width int
+	height int
+	sinceKeyframe int // Deltas sent since the last keyframe
+}
+
 type Broadcaster struct {
 	world *World
 	clients map[*websocket.Conn]bool
@@ -26,7 +41,13 @@ type Broadcaster struct {
 	currentSpeed float64
 	paused bool
 	baseIntervalMs int64 // Base for 1x
+	tickInterval time.Duration // Current updateTicker interval
+	maxFPS int // Cap on tick frames per second (0 = every tick)
+	lastFrameTick int64 // Tick of the last tick frame sent, -1 before the first
 	WriteMu map[*websocket.Conn]*sync.Mutex // Per=conn write locks
+	grids map[*websocket.Conn]*clientGrid // Per-conn last sent grid (for deltas)
+	quit chan struct{} // Closed by Stop to end Run
+	stopOnce sync.Once
 }
 
 func NewBroadcaster(w *World) *Broadcaster {
@@ -39,7 +60,11 @@ func NewBroadcaster(w *World) *Broadcaster {
 		currentSpeed: 1.0,
 		paused: false,
 		baseIntervalMs: 250,
+		maxFPS: DefaultMaxFPS,
+		lastFrameTick: -1,
 		WriteMu: make(map[*websocket.Conn]*sync.Mutex),
+		grids: make(map[*websocket.Conn]*clientGrid),
+		quit: make(chan struct{}),
 	}
 
 	b.resetUpdateTicker()
@@ -59,36 +84,57 @@ func (b *Broadcaster) resetUpdateTicker() {
 		interval = 10 * time.Second // Max for very low speeds
 	}
 	b.updateTicker = time.NewTicker(interval)
+	b.mu.Lock()
+	b.tickInterval = interval
+	b.mu.Unlock()
 	log.Printf("Update ticker reset to %v (speed: %.2fx)", interval, b.currentSpeed)
 }
 
 func (b *Broadcaster) Run() {
-	broadcastTicker := time.NewTicker(100 * time.Millisecond) // Grid broadcast ~10 fps
 	defer func() {
-		broadcastTicker.Stop()
 		if b.updateTicker != nil {
 			b.updateTicker.Stop()
 		}
+
+		// Drop any clients still attached to a stopped room
+		b.mu.Lock()
+		for conn := range b.clients {
+			conn.Close()
+			delete(b.clients, conn)
+			delete(b.WriteMu, conn)
+			delete(b.grids, conn)
+		}
+		b.mu.Unlock()
 	}()
 
 	for {
 		select {
+		case <-b.quit:
+			return
+
 		case conn := <-b.register:
-			b.clients[conn] = true
+			grid := &clientGrid{}
+			mu := &sync.Mutex{}
 			b.mu.Lock() // Use mu for consistency
-            b.WriteMu[conn] = &sync.Mutex{} // Init lock
-            b.mu.Unlock()
-
-            // Send initial world state
-            grid := b.world.GetGridCopy()
-            b.WriteMu[conn].Lock()
-            if err := conn.WriteMessage(websocket.BinaryMessage, grid); err != nil {
-                log.Println("Initial send error:", err)
-                conn.Close()
-                delete(b.clients, conn)
-                delete(b.WriteMu, conn) // Cleanup
-            }
-            b.WriteMu[conn].Unlock()
+			b.clients[conn] = true
+			b.WriteMu[conn] = mu // Init lock
+			b.grids[conn] = grid
+			b.mu.Unlock()
+
+			// Send initial world state (always a keyframe, grid is empty)
+			mu.Lock()
+			err := b.writeGrid(conn, grid, b.world.GridFrame())
+			mu.Unlock()
+			if err != nil {
+				log.Println("Initial send error:", err)
+				b.mu.Lock()
+				conn.Close()
+				delete(b.clients, conn)
+				delete(b.WriteMu, conn) // Cleanup
+				delete(b.grids, conn)
+				b.mu.Unlock()
+				continue
+			}
 
 			// Send initial state
 			b.sendStatsTo(conn)
@@ -98,37 +144,20 @@ func (b *Broadcaster) Run() {
 			if _, ok := b.clients[conn]; ok {
 				delete(b.clients, conn)
 				delete(b.WriteMu, conn)
+				delete(b.grids, conn)
 				conn.Close()
 			}
 			b.mu.Unlock()
 
-		case <-broadcastTicker.C:
-			grid := b.world.GetGridCopy()
-
-			b.mu.RLock()
-			for conn := range b.clients {
-				if mu, ok := b.WriteMu[conn]; ok {
-					mu.Lock()
-					if err := conn.WriteMessage(websocket.BinaryMessage, grid); err != nil {
-						log.Println("Broadcast error:", err)
-						conn.Close()
-						mu.Unlock()
-						// Defer full cleanup to unregister channel
-						b.unregister <- conn
-						continue
-					}
-					mu.Unlock()
-				}
-			}
-			b.mu.RUnlock()
-
 		case <-b.updateTicker.C:
 			b.mu.RLock()
 			p := b.paused
 			b.mu.RUnlock()
 			if !p {
 				b.world.Update() // Run sim tick
-				b.BroadcastStats()
+				if b.broadcastTickFrame() {
+					b.BroadcastStats() // Same rate as grid frames
+				}
 			}
 
 		case <-b.updateChan:
@@ -138,11 +167,35 @@ func (b *Broadcaster) Run() {
 }
 
 func (b *Broadcaster) Register(conn *websocket.Conn) {
-	b.register <- conn
+	select {
+	case b.register <- conn:
+
+	case <-b.quit:
+		conn.Close() // Room already torn down
+	}
 }
 
 func (b *Broadcaster) Unregister(conn *websocket.Conn) {
-	b.unregister <- conn
+	select {
+	case b.unregister <- conn:
+
+	case <-b.quit:
+		// Run already closed every connection on the way out
+	}
+}
+
+// Stop ends Run and closes all client connections. Safe to call more than once
+func (b *Broadcaster) Stop() {
+	b.stopOnce.Do(func() {
+		close(b.quit)
+	})
+}
+
+// Number of currently registered clients
+func (b *Broadcaster) ClientCount() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.clients)
 }
 
 // Set speed and reset ticker
@@ -161,6 +214,35 @@ func (b *Broadcaster) SetSpeed(speed float64) {
 	b.BroadcastStats()
 }
 
+// Caps tick frames per second, at higher sim speeds ticks are skipped.
+// 0 sends every tick
+func (b *Broadcaster) SetMaxFPS(fps int) {
+	if fps < 0 {
+		fps = 0
+	}
+	b.mu.Lock()
+	b.maxFPS = fps
+	b.mu.Unlock()
+
+	b.BroadcastStats()
+}
+
+// How many ticks apart tick frames are at the current speed and fps cap
+func (b *Broadcaster) frameSkip() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.maxFPS <= 0 || b.tickInterval <= 0 {
+		return 1
+	}
+	minGap := time.Second / time.Duration(b.maxFPS)
+	skip := int64((minGap + b.tickInterval - 1) / b.tickInterval) // Round up
+	if skip < 1 {
+		skip = 1
+	}
+	return skip
+}
+
 // Toggle pause
 func (b *Broadcaster) TogglePause() {
 	b.mu.Lock()
@@ -169,43 +251,102 @@ func (b *Broadcaster) TogglePause() {
 	b.BroadcastStats()
 }
 
-// Send stats to a single client
-func (b *Broadcaster) sendStatsTo(conn *websocket.Conn) {
-	b.mu.RLock()
+// Stats payload shared by sendStatsTo and BroadcastStats, gathered in one
+// pass over the grid under a single world read lock
+func (b *Broadcaster) buildStats() ([]byte, error) {
+    b.mu.RLock()
     speed := b.currentSpeed
     paused := b.paused
+    maxFPS := b.maxFPS
     b.mu.RUnlock()
-   
-    counts := b.world.CountEntitiesByTribe()
-	
-	tribeStats := make(map[string]map[string]interface{})
-
-	b.world.Mu.RLock() // Need to read Tribes map
-	for tribeID, cfg := range b.world.Tribes {
-		strID := fmt.Sprintf("%d", tribeID)
-		count := counts[tribeID]
-		wood, stone := b.world.GetTribeResources(tribeID)
-
-		tribeStats[strID] = map[string]interface{}{
-			"count": count,
-			"wood": wood,
-			"stone": stone,
-			"name": cfg.Name,
-		}
-	}
-	b.world.Mu.RUnlock()
+    frameSkip := b.frameSkip()
+
+    w := b.world
+    w.Mu.RLock()
+    defer w.Mu.RUnlock()
+
+    counts := make(map[uint8]int) // Units ashore and at sea, like CountEntitiesByTribe
+    villages := make(map[uint8]int)
+    ageSums := make(map[uint8]int64) // Over units ashore, like AverageAgeByTribe
+    ashore := make(map[uint8]int64)
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
+            if ent := w.Entities[y][x]; ent != nil {
+                counts[ent.Tribe]++
+                ageSums[ent.Tribe] += w.age(ent)
+                ashore[ent.Tribe]++
+            }
+            if v := w.villages[y][x]; v != nil {
+                villages[v.Tribe]++
+            }
+            if boat := w.boats[y][x]; boat != nil && !w.adrift(boat, x, y) {
+                counts[boat.Tribe] += len(boat.Passengers)
+            }
+        }
+    }
+
+    tribeStats := make(map[string]map[string]interface{})
+    for tribeID, cfg := range w.Tribes {
+        res := TribeResources{}
+        if r, ok := w.resources[tribeID]; ok {
+            res = *r
+        }
+
+        techs := []string{}
+        researching := ""
+        if r := w.research[tribeID]; r != nil {
+            for _, t := range r.Known {
+                techs = append(techs, t.String())
+            }
+            if r.Current != TechNone {
+                researching = r.Current.String()
+            }
+        }
+
+        avgAge := 0.0
+        if ashore[tribeID] > 0 {
+            avgAge = float64(ageSums[tribeID]) / float64(ashore[tribeID])
+        }
+
+        tribeStats[fmt.Sprintf("%d", tribeID)] = map[string]interface{}{
+            "count": counts[tribeID],
+            "wood": res.Wood,
+            "stone": res.Stone,
+            "food": res.Food,
+            "ore": res.Ore,
+            "name": cfg.Name,
+            "villages": villages[tribeID],
+            "techs": techs,
+            "researching": researching,
+            "avgAge": avgAge,
+        }
+    }
 
     stats := map[string]interface{}{
         "speed": speed,
         "paused": paused,
-		"tribes": tribeStats,
+        "tribes": tribeStats,
+        "seed": w.seed,
+        "tick": w.tickCount,
+        "frameSkip": frameSkip,
+        "maxFps": maxFPS,
+        "relations": w.relationInfos(),
+        "diplomacyAI": w.diplomacyAI,
+        "buyRanks": w.EntityStats.BuyRanks,
+        "season": w.season().String(),
+        "weather": w.weather.String(),
     }
 
-	if winner := b.world.GetWinner(); winner != "" {
-		stats["winner"] = winner
-	}
+    if w.winner != "" {
+        stats["winner"] = w.winner
+    }
+
+    return json.Marshal(stats)
+}
 
-    data, err := json.Marshal(stats)
+// Send stats to a single client
+func (b *Broadcaster) sendStatsTo(conn *websocket.Conn) {
+    data, err := b.buildStats()
     if err != nil {
         log.Println("Stats marshal error:", err)
         return
@@ -219,68 +360,57 @@ func (b *Broadcaster) sendStatsTo(conn *websocket.Conn) {
 }
 
 func (b *Broadcaster) BroadcastStats() {
-    b.mu.RLock()
-    speed := b.currentSpeed
-    paused := b.paused
-    b.mu.RUnlock()
-    
-    counts := b.world.CountEntitiesByTribe()
-    
-    tribeStats := make(map[string]map[string]interface{})
-
-	b.world.Mu.RLock() // Need to read Tribes map
-	for tribeID, cfg := range b.world.Tribes {
-		strID := fmt.Sprintf("%d", tribeID)
-		count := counts[tribeID]
-		wood, stone := b.world.GetTribeResources(tribeID)
-
-		tribeStats[strID] = map[string]interface{}{
-			"count": count,
-			"wood": wood,
-			"stone": stone,
-			"name": cfg.Name,
-		}
-	}
-	b.world.Mu.RUnlock()
-
-    for tribeID := range b.world.Tribes {
-        strID := fmt.Sprintf("%d", tribeID)
-        count := counts[tribeID]
-        wood, stone := b.world.GetTribeResources(tribeID)
-        tribeStats[strID] = map[string]interface{}{
-            "count": count,
-            "wood": wood,
-            "stone": stone,
-        }
+    data, err := b.buildStats()
+    if err != nil {
+        log.Println("Stats marshal error:", err)
+        return
     }
 
-    stats := map[string]interface{}{
-        "speed": speed,
-        "paused": paused,
-        "tribes": tribeStats,
-    }
-   
-    if winner := b.world.GetWinner(); winner != "" {
-        stats["winner"] = winner
-    }
+    b.broadcastText(data, "Stats")
+}
 
-    data, err := json.Marshal(stats)
+// Sends a one-off event (e.g. a PowerEvent) to every client as JSON
+func (b *Broadcaster) BroadcastEvent(event interface{}) {
+    data, err := json.Marshal(event)
     if err != nil {
-        log.Println("Stats marshal error:", err)
+        log.Println("Event marshal error:", err)
         return
     }
 
+    b.broadcastText(data, "Event")
+}
+
+// Writes v as JSON to one client under its write lock, safe from any
+// goroutine. Does nothing once the client has gone
+func (b *Broadcaster) WriteJSON(conn *websocket.Conn, v interface{}) error {
+    data, err := json.Marshal(v)
+    if err != nil {
+        return err
+    }
+
+    b.mu.RLock()
+    mu, ok := b.WriteMu[conn]
+    b.mu.RUnlock()
+    if !ok {
+        return nil
+    }
+
+    mu.Lock()
+    defer mu.Unlock()
+    return conn.WriteMessage(websocket.TextMessage, data)
+}
+
+// Writes a text message to every client, what names it in the logs
+func (b *Broadcaster) broadcastText(data []byte, what string) {
     b.mu.RLock()
     for conn := range b.clients {
         if mu, ok := b.WriteMu[conn]; ok {
             mu.Lock()
             if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
-                log.Println("Stats broadcast error:", err)
+                log.Println(what, "broadcast error:", err)
                 mu.Unlock()
                 // Defer cleanup to unregister channel
-                go func(c *websocket.Conn) {
-                    b.unregister <- c
-                }(conn)
+                go b.Unregister(conn)
                 continue
             }
             mu.Unlock()
@@ -289,23 +419,92 @@ func (b *Broadcaster) BroadcastStats() {
     b.mu.RUnlock()
 }
 
+// Sends the frame for the tick that just ran, at most once per tick and only
+// every frameSkip ticks. A game-ending tick is never skipped. True if it went out
+func (b *Broadcaster) broadcastTickFrame() bool {
+    frame := b.world.GridFrame()
+    tick := gridFrameTick(frame)
+
+    b.mu.RLock()
+    last := b.lastFrameTick
+    b.mu.RUnlock()
+
+    if tick == last {
+        return false // Update was a no-op (game over), this tick already went out
+    }
+    if tick > last && tick-last < b.frameSkip() && !b.world.IsGameOver() {
+        return false // Skipped at this speed, tick < last means the world was reset
+    }
+
+    b.mu.Lock()
+    b.lastFrameTick = tick
+    b.mu.Unlock()
+
+    b.sendGrid(frame)
+    return true
+}
+
+// Sends every client the change since its last frame (or a keyframe when due).
+// For player edits between ticks, flagged so clients don't take it as a new tick
 func (b *Broadcaster) BroadcastGrid() {
-    grid := b.world.GetGridCopy()
-    
+    frame := b.world.GridFrame()
+    frame[0] |= FrameFlagEdit
+    b.sendGrid(frame)
+}
+
+func (b *Broadcaster) sendGrid(frame []byte) {
     b.mu.RLock()
     for conn := range b.clients {
-        if mu, ok := b.WriteMu[conn]; ok {
-            mu.Lock()
-            if err := conn.WriteMessage(websocket.BinaryMessage, grid); err != nil {
-                log.Println("Grid broadcast error:", err)
-                mu.Unlock()
-                go func(c *websocket.Conn) {
-                    b.unregister <- c
-                }(conn)
-                continue
-            }
-            mu.Unlock()
+        mu, ok := b.WriteMu[conn]
+        grid := b.grids[conn]
+        if !ok || grid == nil {
+            continue
+        }
+
+        mu.Lock()
+        err := b.writeGrid(conn, grid, frame)
+        mu.Unlock()
+        if err != nil {
+            log.Println("Grid broadcast error:", err)
+            go b.Unregister(conn)
         }
     }
     b.mu.RUnlock()
-}
\ No newline at end of file
+}
+
+// Writes frame to conn as a delta against grid, or as a keyframe on join, resize,
+// every keyframeEvery frames, or when the delta would be bigger. An edit that
+// changed nothing for the client isn't sent, a quiet tick goes out as an empty
+// delta so the client doesn't count it as dropped. Caller holds WriteMu[conn]
+func (b *Broadcaster) writeGrid(conn *websocket.Conn, grid *clientGrid, frame []byte) error {
+    width, height := gridFrameSize(frame)
+    flags := frame[0] & FrameFlagEdit
+    cells := frame[gridHeaderLen:]
+
+    msg := frame
+    sameSize := grid.cells != nil && grid.width == width && grid.height == height
+    if sameSize && grid.sinceKeyframe < keyframeEvery {
+        delta := encodeGridDelta(FrameGridDelta|flags, gridFrameTick(frame), width, height, grid.cells, cells)
+        if flags != 0 && len(delta) == gridHeaderLen+4 {
+            return nil // Edit that changed nothing
+        }
+        if len(delta) < len(frame) {
+            msg = delta
+        }
+    }
+
+    if err := conn.WriteMessage(websocket.BinaryMessage, msg); err != nil {
+        return err
+    }
+
+    if msg[0]&^FrameFlagEdit == FrameFullGrid {
+        grid.sinceKeyframe = 0
+    } else {
+        grid.sinceKeyframe++
+    }
+    grid.cells = append(grid.cells[:0], cells...)
+    grid.width = width
+    grid.height = height
+
+    return nil
+}
@@ -0,0 +1,47 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// The one-pass stats agree with the per-field getters
+func TestBuildStatsMatchesGetters(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr~bbb", "11...2.")
+	w.villages[0][0] = &Village{Tribe: 1, Level: 1}
+	w.boats[0][3] = &Boat{Tribe: 2, Passengers: []*Entity{{Health: 100, Tribe: 2, ID: 9, Rank: RankBase}}}
+	w.resources[1] = &TribeResources{Wood: 7, Food: 3}
+	w.Entities[0][0].Born = -20
+
+	b := NewBroadcaster(w)
+	defer b.updateTicker.Stop()
+	data, err := b.buildStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stats struct {
+		Tick   int64 `json:"tick"`
+		Tribes map[string]struct {
+			Count    int     `json:"count"`
+			Wood     int64   `json:"wood"`
+			Villages int     `json:"villages"`
+			AvgAge   float64 `json:"avgAge"`
+		} `json:"tribes"`
+	}
+	if err := json.Unmarshal(data, &stats); err != nil {
+		t.Fatal(err)
+	}
+
+	counts, villages, ages := w.CountEntitiesByTribe(), w.CountVillagesByTribe(), w.AverageAgeByTribe()
+	for _, tribe := range []uint8{1, 2} {
+		got := stats.Tribes[fmt.Sprintf("%d", tribe)]
+		if got.Count != counts[tribe] || got.Villages != villages[tribe] || got.AvgAge != ages[tribe] {
+			t.Fatalf("tribe %d stats %+v, want count %d villages %d age %v", tribe, got, counts[tribe], villages[tribe], ages[tribe])
+		}
+	}
+	if stats.Tribes["1"].Wood != 7 || stats.Tribes["2"].Count != 2 {
+		t.Fatalf("stats %+v", stats.Tribes)
+	}
+}
@@ -0,0 +1,230 @@
+package world
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Relation between a pair of tribes. Tribes start at war with everyone, only
+// pairs at war fight, chase each other or take each other's land and villages.
+// The war ends once the survivors are all allied, and they win together.
+// Tribes merely at peace don't share a win, their war goes on (with the AI
+// on, until the next decision finds nobody else left to fight)
+type Relation uint8
+
+const (
+	RelationWar      Relation = 0 // Default for every pair
+	RelationPeace    Relation = 1 // Leave each other alone
+	RelationAlliance Relation = 2 // Leave each other alone, and the AI keeps them together against a leader
+)
+
+// Diplomacy AI tuning (war only)
+const (
+	DiplomacyTicks      = 50   // War ticks between AI decisions
+	DominantShare       = 0.4  // Share of total strength that makes a tribe the one to gang up on
+	AllianceBreakChance = 0.15 // Per decision, for each alliance once nobody dominates
+)
+
+func (r Relation) String() string {
+	switch r {
+	case RelationPeace:
+		return "peace"
+
+	case RelationAlliance:
+		return "alliance"
+
+	default:
+		return "war"
+	}
+}
+
+func ParseRelation(s string) (Relation, bool) {
+	switch s {
+	case "war":
+		return RelationWar, true
+
+	case "peace":
+		return RelationPeace, true
+
+	case "alliance":
+		return RelationAlliance, true
+
+	default:
+		return RelationWar, false
+	}
+}
+
+// Map key for a tribe pair, lower ID first
+func relationKey(a, b uint8) [2]uint8 {
+	if a > b {
+		a, b = b, a
+	}
+
+	return [2]uint8{a, b}
+}
+
+// Caller holds Mu
+func (w *World) relation(a, b uint8) Relation {
+	return w.relations[relationKey(a, b)]
+}
+
+// Caller holds Mu
+func (w *World) setRelation(a, b uint8, r Relation) {
+	if r == RelationWar {
+		delete(w.relations, relationKey(a, b))
+		return
+	}
+	w.relations[relationKey(a, b)] = r
+}
+
+// True if a and b are different tribes at war, caller holds Mu
+func (w *World) hostile(a, b uint8) bool {
+	return a != b && w.relation(a, b) == RelationWar
+}
+
+// Sets the relation between two tribes of the current map
+func (w *World) SetRelation(a, b uint8, r Relation) error {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+
+	if a == b {
+		return fmt.Errorf("tribe %d can't have a relation with itself", a)
+	}
+	if _, ok := w.Tribes[a]; !ok {
+		return fmt.Errorf("unknown tribe %d", a)
+	}
+	if _, ok := w.Tribes[b]; !ok {
+		return fmt.Errorf("unknown tribe %d", b)
+	}
+
+	w.setRelation(a, b, r)
+	return nil
+}
+
+// One entry per tribe pair, for stats
+type RelationInfo struct {
+	A        uint8  `json:"a"`
+	B        uint8  `json:"b"`
+	Relation string `json:"relation"`
+}
+
+// Every pair of current tribes with its relation, in tribe order
+func (w *World) Relations() []RelationInfo {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+	return w.relationInfos()
+}
+
+// Relations for a caller that holds Mu
+func (w *World) relationInfos() []RelationInfo {
+	ids := w.sortedTribeIDs()
+	out := []RelationInfo{}
+	for i, a := range ids {
+		for _, b := range ids[i+1:] {
+			out = append(out, RelationInfo{A: a, B: b, Relation: w.relation(a, b).String()})
+		}
+	}
+
+	return out
+}
+
+func (w *World) SetDiplomacyAI(on bool) {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+	w.diplomacyAI = on
+}
+
+func (w *World) DiplomacyAI() bool {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+	return w.diplomacyAI
+}
+
+// Winner string for a set of surviving tribes, "1" or "1+3" for allies
+func coalitionName(tribes []uint8) string {
+	parts := make([]string, len(tribes))
+	for i, t := range tribes {
+		parts[i] = fmt.Sprintf("%d", t)
+	}
+
+	return strings.Join(parts, "+")
+}
+
+// War-time AI, every DiplomacyTicks. A tribe holding more than DominantShare
+// of the strength loses every friend, and the two weakest others ally against
+// it. With nobody dominating, alliances fall apart now and then. Once none
+// of the survivors are at war, peace only stalls the end and goes back to war
+func (w *World) runDiplomacyAI(ents [][]*Entity) {
+	if !w.diplomacyAI || w.tickCount%DiplomacyTicks != 0 {
+		return
+	}
+
+	// Health-weighted fighting power per tribe
+	strength := make(map[uint8]float64)
+	total := 0.0
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if ent := ents[y][x]; ent != nil {
+				s := float64(ent.Health) / 100 * float64(ent.TotalDamage(w)+ent.TotalArmor(w))
+				strength[ent.Tribe] += s
+				total += s
+			}
+		}
+	}
+	alive := make([]uint8, 0, len(strength))
+	for tribe := range strength {
+		alive = append(alive, tribe)
+	}
+	w.endStalledPeace(alive)
+
+	if len(strength) < 3 || total == 0 {
+		return // Two tribes can only fight it out
+	}
+
+	// Strongest first, ties by ID so replays match
+	sort.Slice(alive, func(i, j int) bool {
+		if strength[alive[i]] != strength[alive[j]] {
+			return strength[alive[i]] > strength[alive[j]]
+		}
+		return alive[i] < alive[j]
+	})
+
+	leader := alive[0]
+	if strength[leader]/total > DominantShare {
+		for _, other := range alive[1:] {
+			w.setRelation(leader, other, RelationWar)
+		}
+		weakest, next := alive[len(alive)-1], alive[len(alive)-2]
+		w.setRelation(weakest, next, RelationAlliance)
+		return
+	}
+
+	for i, a := range alive {
+		for _, b := range alive[i+1:] {
+			if w.relation(a, b) == RelationAlliance && w.rng.Float64() < AllianceBreakChance {
+				w.setRelation(a, b, RelationWar)
+			}
+		}
+	}
+}
+
+// Puts every pair of survivors at peace back at war when no pair of them is
+// at war, else nobody could ever win. Allies stay allied, caller holds Mu
+func (w *World) endStalledPeace(alive []uint8) {
+	for i, a := range alive {
+		for _, b := range alive[i+1:] {
+			if w.hostile(a, b) {
+				return // Still a war to fight
+			}
+		}
+	}
+
+	for i, a := range alive {
+		for _, b := range alive[i+1:] {
+			if w.relation(a, b) == RelationPeace {
+				w.setRelation(a, b, RelationWar)
+			}
+		}
+	}
+}
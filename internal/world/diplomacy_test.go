@@ -0,0 +1,141 @@
+package world
+
+import "testing"
+
+func TestAlliesDontFight(t *testing.T) {
+	w := newTestWorld(t, 1, "hhh#b", "12.#3")
+	w.EntityStats.MoveChance = 0
+	if err := w.SetRelation(1, 2, RelationAlliance); err != nil {
+		t.Fatal(err)
+	}
+	w.StartWar()
+
+	a, b := w.Entities[0][0], w.Entities[0][1]
+	w.Update()
+
+	// Minimum hit of 1 plus hill attrition of 3, nothing from each other
+	if a.Health != 96 || b.Health != 96 {
+		t.Fatalf("allies at %d and %d health, want 96 each", a.Health, b.Health)
+	}
+	if w.IsGameOver() {
+		t.Fatal("war over while tribe 3 is still at war with both")
+	}
+}
+
+func TestEnemyTerrainRespectsRelations(t *testing.T) {
+	w := newTestWorld(t, 1, "rb", "")
+	if !IsEnemyTerrain(TerrainBlue, w, 1) {
+		t.Fatal("blue isn't enemy terrain for red at war")
+	}
+
+	w.setRelation(1, 2, RelationPeace)
+	if IsEnemyTerrain(TerrainBlue, w, 1) {
+		t.Fatal("blue is enemy terrain for red at peace")
+	}
+}
+
+func TestAlliesWinTogether(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rrbby
+rrbby
+`, `
+1.2.3
+1....
+`)
+	w.EntityStats.MoveChance = 0
+	w.setRelation(1, 2, RelationAlliance)
+	w.Entities[0][4] = nil // Tribe 3 is already gone, only its land is left
+	w.StartWar()
+	w.Update()
+
+	if !w.IsGameOver() || w.GetWinner() != "1+2" {
+		t.Fatalf("game over %v winner %q, want allies \"1+2\"", w.IsGameOver(), w.GetWinner())
+	}
+	if n := w.CountTerrain(uint8(TerrainYellow)); n != 0 {
+		t.Fatalf("%d cells of the fallen tribe left", n)
+	}
+	if n := w.CountTerrain(uint8(TerrainBlue)); n != 4 {
+		t.Fatalf("ally lost land to the lead winner, %d blue cells left", n)
+	}
+}
+
+func TestPeaceDoesntShareAWin(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rrbb
+`, `
+1.2.
+`)
+	w.EntityStats.MoveChance = 0
+	w.setRelation(1, 2, RelationPeace)
+	w.StartWar()
+	w.Update()
+
+	if w.IsGameOver() {
+		t.Fatalf("game ended with winner %q, tribes at peace aren't allies", w.GetWinner())
+	}
+}
+
+// The last two tribes at peace would stall the game forever, the AI ends it
+func TestDiplomacyAIEndsStalledPeace(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rr^bb
+`, `
+1...2
+`)
+	w.EntityStats.MoveChance = 0
+	w.SetDiplomacyAI(true)
+	w.setRelation(1, 2, RelationPeace)
+	w.StartWar()
+
+	for i := 0; i < DiplomacyTicks; i++ {
+		w.Update()
+	}
+	if r := w.relation(1, 2); r != RelationWar {
+		t.Fatalf("last two tribes still at %v", r)
+	}
+}
+
+func TestDiplomacyAIGangsUpOnLeader(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rrrrrbby
+`, `
+11111223
+`)
+	w.setRelation(1, 2, RelationAlliance)
+	w.diplomacyAI = true
+	w.tickCount = DiplomacyTicks
+
+	w.runDiplomacyAI(w.Entities)
+	if r := w.relation(1, 2); r != RelationWar {
+		t.Fatalf("leader still has relation %v with tribe 2", r)
+	}
+	if r := w.relation(2, 3); r != RelationAlliance {
+		t.Fatalf("two weakest have relation %v, want alliance", r)
+	}
+}
+
+func TestSetRelationRejectsBadPairs(t *testing.T) {
+	w := newTestWorld(t, 1, "rb", "")
+	if err := w.SetRelation(1, 1, RelationPeace); err == nil {
+		t.Fatal("relation with itself accepted")
+	}
+	if err := w.SetRelation(1, 9, RelationPeace); err == nil {
+		t.Fatal("relation with unknown tribe accepted")
+	}
+}
+
+func TestSnapshotKeepsRelations(t *testing.T) {
+	w := newMapWorld(t, 7, "fourquadrants", 30, 30)
+	w.setRelation(3, 1, RelationAlliance)
+	w.setRelation(2, 4, RelationPeace)
+	w.diplomacyAI = true
+
+	restored := roundTrip(t, w)
+
+	if restored.relation(1, 3) != RelationAlliance || restored.relation(2, 4) != RelationPeace || restored.relation(1, 2) != RelationWar {
+		t.Fatalf("relations after restore: %v", restored.Relations())
+	}
+	if !restored.DiplomacyAI() {
+		t.Fatal("diplomacy AI switched off by restore")
+	}
+}
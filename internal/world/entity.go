@@ -2,14 +2,15 @@ package world
 
 import (
 	"sync/atomic"
-	"time"
 )
 
 type EntityStats struct {
 	MoveChance float64 // Chance to attempt move
 	ReproductionRate float64 // Chance to reproduce if space
     MaxDensityFraction float64 // Max fraction occupied before skipping reprod (0-1)
-    ReprodCooldown time.Duration // Cooldown in seconds after reprod
+    ReprodCooldownTicks int64 // Cooldown in sim ticks after reprod (scales with speed/pause)
+    ArcherChance float64 // Chance an unarmed entity arming up goes for a bow instead of a sword
+    BuyRanks bool // Tribes may buy ranks with wood in peace, otherwise only kills promote (see veterancy.go)
 }
 
 type Rank uint8
@@ -84,35 +85,91 @@ type Entity struct {
     ID uint32 // Unique sequential per tribe
     Evasion float64 // Chance to evade incoming attacks
     Rank Rank
+    Morale int // 0-MaxMorale, routed below RetreatMorale (see morale.go)
+    Born int64 // Tick of birth, starters can be born before tick 0 (see aging.go)
+    Kills int // Killing blows landed in war
+    Leader bool // Its tribe's leader (see leader.go)
+    MovePoints int // Saved towards the next step, see movement.go
+    inspired bool // In reach of its leader this war tick, set by applyAuras
 }
 
 type TribeResources struct {
     Wood int64
     Stone int64
+    Food int64 // See food.go
+    Ore int64 // For iron/steel gear and research, see tech.go
+}
+
+// One cell of a place_batch
+type Placement struct {
+    X int `json:"x"`
+    Y int `json:"y"`
+    Type uint8 `json:"type"`
 }
 
 func (w *World) PlaceEntity(x, y int, typ uint8) bool {
-	w.Mu.Lock()
+    return w.PlaceBatch([]Placement{{X: x, Y: y, Type: typ}})
+}
+
+// Places every cell under one lock, true if any took. A tribe with no units or
+// villages before the batch gets a starting village at its first unit, as map
+// init does, so tribes painted onto a reset world can breed. Who's unsettled
+// is worked out in one scan per batch, not per unit
+func (w *World) PlaceBatch(places []Placement) bool {
+    w.Mu.Lock()
     defer w.Mu.Unlock()
 
-    if x < 0 || x >= GridSize || y < 0 || y >= GridSize {
+    unsettled := w.unsettledTribes()
+    firstUnit := make(map[uint8][2]int)
+    placed := false
+    for _, p := range places {
+        if !w.placeCell(p.X, p.Y, p.Type) {
+            continue
+        }
+        placed = true
+
+        ent := w.Entities[p.Y][p.X]
+        if p.Type != 3 || ent == nil || !unsettled[ent.Tribe] {
+            continue
+        }
+        if _, ok := firstUnit[ent.Tribe]; !ok {
+            firstUnit[ent.Tribe] = [2]int{p.X, p.Y}
+        }
+    }
+
+    for _, tribe := range w.sortedTribeIDs() {
+        if pos, ok := firstUnit[tribe]; ok {
+            w.settleFirstUnit(tribe, pos)
+        }
+    }
+
+    return placed
+}
+
+// PlaceEntity's work for one cell, caller holds Mu
+func (w *World) placeCell(x, y int, typ uint8) bool {
+    if x < 0 || x >= w.Width || y < 0 || y >= w.Height {
         return false // Out of bounds
     }
 
-    if typ > 10 {
+    if typ > 10 && typ != uint8(TerrainBerries) && typ != uint8(TerrainOre) && typ != uint8(TerrainWater) && typ != uint8(TerrainFord) {
         return false // Invalid type
     }
     
-    terrain := w.Terrain[y * GridSize + x]
+    terrain := w.Terrain[y*w.Width + x]
     if typ == 0 {
-        w.Terrain[y * GridSize + x] = 0
+        w.Terrain[y*w.Width + x] = 0
         w.Entities[y][x] = nil
-        w.lastReprodTime[y][x] = time.Time{}
+        w.lastReprodTick[y][x] = 0
+        w.villages[y][x] = nil
+        w.boats[y][x] = nil
 
     } else if typ == 1 || typ == 2 || typ == 4 || typ == 9 || typ == 10 {
-        w.Terrain[y * GridSize + x] = typ
+        w.Terrain[y*w.Width + x] = typ
         w.Entities[y][x] = nil // Remove any entity
-        w.lastReprodTime[y][x] = time.Time{}
+        w.lastReprodTick[y][x] = 0
+        w.villages[y][x] = nil // Painting over a village razes it
+        w.boats[y][x] = nil // Boats sink on dry land
 
     } else if typ == 3 {
         terrainType := TerrainType(terrain)
@@ -139,14 +196,20 @@ func (w *World) PlaceEntity(x, y int, typ uint8) bool {
 			ID: id,
             Evasion: evasion,
             Rank: RankBase,
+            Morale: MaxMorale,
+            Born: w.tickCount,
 		}
-		w.lastReprodTime[y][x] = time.Time{}
+		w.lastReprodTick[y][x] = 0
 		return true
 
-    } else if typ == 6 || typ == 7 || typ == 8 { // New neutral terrain
-        w.Terrain[y*GridSize + x] = typ
+    } else if typ == 6 || typ == 7 || typ == 8 || typ == uint8(TerrainBerries) || typ == uint8(TerrainOre) || typ == uint8(TerrainWater) || typ == uint8(TerrainFord) { // New neutral terrain
+        w.Terrain[y*w.Width + x] = typ
         w.Entities[y][x] = nil
-        w.lastReprodTime[y][x] = time.Time{}
+        w.lastReprodTick[y][x] = 0
+        w.villages[y][x] = nil
+        if typ != uint8(TerrainWater) {
+            w.boats[y][x] = nil
+        }
         
         return true
     }
@@ -173,35 +236,37 @@ func (w *World) CountEntitiesByTribe() map[uint8]int {
     defer w.Mu.RUnlock()
 
     counts := make(map[uint8]int)
-	for y := 0; y < GridSize; y++ {
-		for x := 0; x < GridSize; x++ {
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
 			ent := w.Entities[y][x]
 			if ent != nil {
 				counts[ent.Tribe]++
 			}
 		}
 	}
+	w.countPassengers(counts)
 
 	return counts
 }
 
 func (w *World) GetEntity(x, y int) *Entity {
-    if x < 0 || x >= GridSize || y < 0 || y >= GridSize {
-        return nil
-    }
-
     w.Mu.RLock()
     defer w.Mu.RUnlock()
 
+    if x < 0 || x >= w.Width || y < 0 || y >= w.Height {
+        return nil
+    }
+
     return w.Entities[y][x]
 }
 
-func (w *World) GetTribeResources(tribe uint8) (wood, stone int64) {
+// Copy of the tribe's stockpile, zero if it has none yet
+func (w *World) GetTribeResources(tribe uint8) TribeResources {
     w.Mu.RLock()
     defer w.Mu.RUnlock()
     if res, ok := w.resources[tribe]; ok {
-        return res.Wood, res.Stone
+        return *res
     }
 
-    return 0, 0
+    return TribeResources{}
 }
\ No newline at end of file
@@ -0,0 +1,107 @@
+package world
+
+// Forest fires, a cellular automaton over the terrain. Every tick a burning
+// cell (TerrainFire) lights each neighbouring tree with FireSpreadChance,
+// less in the rain, and burns out with FireBurnOutChance to TerrainBurnt,
+// which grows back into trees like a cleared tree does. Units standing in a
+// fire take FireDamage a tick, next to one FireHeatDamage, and nobody walks
+// into one. Fires are lit by the fire god power, by lightning striking a tree
+// and by storms, which strike a random tree with StormLightningChance a tick.
+// Burning and regrowth both run in peace and war
+const (
+	FireSpreadChance     = 0.3
+	RainSpreadCut        = 0.5
+	FireBurnOutChance    = 0.2
+	FireDamage           = 15
+	FireHeatDamage       = 5
+	StormLightningChance = 0.05
+)
+
+// Sets the tree at (x, y) alight, anything else won't burn. caller holds Mu
+func (w *World) ignite(x, y int) {
+	if TerrainType(w.Terrain[y*w.Width+x]) != TerrainTrees {
+		return
+	}
+
+	w.Terrain[y*w.Width+x] = uint8(TerrainFire)
+	w.lastClearedTick[y][x] = 0
+}
+
+// True if (x, y) or a cell next to it is burning, caller holds Mu
+func (w *World) nearFire(x, y int) bool {
+	for _, dir := range [][2]int{{0, 0}, {0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+		nx, ny := x+dir[0], y+dir[1]
+		if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height && TerrainType(w.Terrain[ny*w.Width+nx]) == TerrainFire {
+			return true
+		}
+	}
+
+	return false
+}
+
+// One tick of fire: storm lightning, spreading, burning the units in ents and
+// burning out. Returns deaths with the units it killed. caller holds Mu
+func (w *World) burnFires(ents [][]*Entity, deaths []death) []death {
+	if w.weather == WeatherStorm && w.rng.Float64() < StormLightningChance {
+		var trees []int
+		for i, t := range w.Terrain {
+			if TerrainType(t) == TerrainTrees {
+				trees = append(trees, i)
+			}
+		}
+		if len(trees) > 0 {
+			i := trees[w.rng.Intn(len(trees))]
+			w.ignite(i%w.Width, i/w.Width)
+		}
+	}
+
+	var burning []int
+	for i, t := range w.Terrain {
+		if TerrainType(t) == TerrainFire {
+			burning = append(burning, i)
+		}
+	}
+	if len(burning) == 0 {
+		return deaths
+	}
+
+	// Only fires that were already burning spread, so a tick moves it one cell
+	spread := FireSpreadChance
+	if w.weather == WeatherRain {
+		spread *= RainSpreadCut
+	}
+	for _, i := range burning {
+		x, y := i%w.Width, i/w.Width
+		for _, dir := range [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+			nx, ny := x+dir[0], y+dir[1]
+			if nx < 0 || nx >= w.Width || ny < 0 || ny >= w.Height || TerrainType(w.Terrain[ny*w.Width+nx]) != TerrainTrees {
+				continue
+			}
+			if w.rng.Float64() < spread {
+				w.ignite(nx, ny)
+			}
+		}
+	}
+
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if ents[y][x] == nil || !w.nearFire(x, y) {
+				continue
+			}
+			dmg := FireHeatDamage
+			if TerrainType(w.Terrain[y*w.Width+x]) == TerrainFire {
+				dmg = FireDamage
+			}
+			deaths = w.hurt(ents, x, y, dmg, deaths)
+		}
+	}
+
+	for _, i := range burning {
+		if w.rng.Float64() < FireBurnOutChance {
+			w.Terrain[i] = uint8(TerrainBurnt)
+			w.lastClearedTick[i/w.Width][i%w.Width] = w.tickCount // Regrows from now
+		}
+	}
+
+	return deaths
+}
@@ -0,0 +1,107 @@
+package world
+
+import "testing"
+
+// Ticks fire alone until nothing burns, fails if it never goes out
+func burnOut(t *testing.T, w *World) {
+	t.Helper()
+
+	for i := 0; i < 500; i++ {
+		w.tickCount++
+		w.burnFires(w.Entities, nil)
+
+		burning := false
+		for _, c := range w.Terrain {
+			burning = burning || TerrainType(c) == TerrainFire
+		}
+		if !burning {
+			return
+		}
+	}
+	t.Fatal("fire never burnt out")
+}
+
+func TestFireSpreadsThroughTreesOnly(t *testing.T) {
+	w := newTestWorld(t, 1, "TTTTT.TT", "") // A seed where it runs the whole line
+	w.ignite(0, 0)
+	burnOut(t, w)
+
+	for x := 0; x < 5; x++ {
+		if got := TerrainType(w.Terrain[x]); got != TerrainBurnt {
+			t.Fatalf("cell %d is %v, want burnt", x, got)
+		}
+	}
+	for x := 6; x < 8; x++ {
+		if TerrainType(w.Terrain[x]) != TerrainTrees {
+			t.Fatalf("fire jumped the gap to cell %d", x)
+		}
+	}
+}
+
+func TestFireBurnsUnitsInAndNextToIt(t *testing.T) {
+	w := newTestWorld(t, 1, "T.rr", "11.1")
+	w.ignite(0, 0)
+	w.burnFires(w.Entities, nil)
+
+	for x, want := range map[int]int{0: 100 - FireDamage, 1: 100 - FireHeatDamage, 3: 100} {
+		if got := w.Entities[0][x].Health; got != want {
+			t.Fatalf("unit at %d has %d health, want %d", x, got, want)
+		}
+	}
+}
+
+func TestBurntGroundRegrows(t *testing.T) {
+	w := newTestWorld(t, 1, "Tr", "")
+	w.ignite(0, 0)
+	burnOut(t, w)
+
+	w.tickCount += w.regrowTicks
+	HandleMiningAndRegrowth(w)
+	if TerrainType(w.Terrain[0]) != TerrainTrees {
+		t.Fatal("burnt ground didn't grow back")
+	}
+}
+
+func TestBurntGroundRegrowsInWar(t *testing.T) {
+	w := newTestWorld(t, 1, "Trrrb", "...12")
+	w.EntityStats.MoveChance = 0
+	w.ignite(0, 0)
+	burnOut(t, w)
+	w.StartWar()
+
+	w.tickCount += w.regrowTicks
+	w.Update()
+	if TerrainType(w.Terrain[0]) != TerrainTrees {
+		t.Fatal("burnt ground didn't grow back during the war")
+	}
+}
+
+func TestFirePowerLightsTreesInReach(t *testing.T) {
+	w := newTestWorld(t, 1, "TrTTT", "")
+	if _, err := w.UseGodPower(PowerFire, 1, 0, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	for x, want := range []TerrainType{TerrainFire, TerrainRed, TerrainFire, TerrainTrees, TerrainTrees} {
+		if got := TerrainType(w.Terrain[x]); got != want {
+			t.Fatalf("cell %d is %v, want %v", x, got, want)
+		}
+	}
+}
+
+func TestFireBurnsInWar(t *testing.T) {
+	w := newTestWorld(t, 1, `
+TTrr.bb
+`, `
+1.....2
+`)
+	w.EntityStats.MoveChance = 0
+	w.Entities[0][0].Health = 1 // Regen off rough ground can't save it
+	w.ignite(0, 0)
+	w.StartWar()
+
+	w.Update()
+	if w.Entities[0][0] != nil {
+		t.Fatal("unit in the fire survived")
+	}
+}
@@ -0,0 +1,136 @@
+package world
+
+// Food is picked from berry bushes and grown by village farms, and every
+// entity eats at each meal. A tribe with an empty larder doesn't reproduce,
+// and one that can't pay for a meal goes hungry and loses health
+const (
+	BerryFood        int64 = 5   // Per bush picked
+	FarmFood         int64 = 10  // Per village level, each meal
+	FoodPerEntity    int64 = 1   // Eaten each meal
+	StartingFood     int64 = 100 // Larder each tribe starts with
+	MealTicks              = 40  // Ticks between meals
+	StarvationDamage       = 15  // Health lost per missed meal, won back one full meal at a time
+)
+
+// Scatters berry patches over home flats, same pattern as the other map features
+func placeBerryPatches(w *World, isHomeFlat func(TerrainType) bool) {
+	tries := placementTries * scaledCount(w, 20)
+	for i := 0; i < scaledCount(w, 20); i++ {
+		cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+		if !ok {
+			break // No flat left to put it on
+		}
+
+		bushes := 5 + w.rng.Intn(5)
+		for j := 0; j < bushes; j++ {
+			nx, ny := cx+w.rng.Intn(7)-3, cy+w.rng.Intn(7)-3
+			if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height && isHomeFlat(TerrainType(w.Terrain[ny*w.Width+nx])) {
+				w.Terrain[ny*w.Width+nx] = uint8(TerrainBerries)
+			}
+		}
+	}
+}
+
+// Fills every tribe's larder so the first meals don't starve them, caller holds Mu
+func (w *World) stockStartingFood() {
+	for tribe := range w.Tribes {
+		res := w.resources[tribe]
+		if res == nil {
+			res = &TribeResources{}
+			w.resources[tribe] = res
+		}
+		res.Food = StartingFood
+	}
+}
+
+// True if the bush at (x, y) has grown back since it was last picked.
+// Bushes reuse lastClearedTick as their picked tick, regrowth ignores them
+func (w *World) berriesReady(x, y int) bool {
+	last := w.lastClearedTick[y][x]
+	return last == 0 || w.tickCount-last >= w.regrowTicks
+}
+
+// True if the tribe has no food left
+func (w *World) larderEmpty(tribe uint8) bool {
+	res := w.resources[tribe]
+	return res == nil || res.Food <= 0
+}
+
+// Meal every MealTicks, in peace and war alike: farms deliver, then each
+// tribe feeds as many entities as it can. The chance an entity goes hungry is
+// the tribe's shortfall, so a famine thins a tribe down to what it can feed
+// instead of wiping it out. Hungry entities lose StarvationDamage and may die,
+// fed ones heal the same amount back if heal is set (war leaves healing to the
+// terrain). Units aboard boats eat too. Returns deaths with those who starved
+func (w *World) eatMeals(ents [][]*Entity, deaths []death, heal bool) []death {
+	if w.tickCount%MealTicks != 0 {
+		return deaths
+	}
+
+	counts := make(map[uint8]int64)
+	farms := make(map[uint8]int64)
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if ent := ents[y][x]; ent != nil {
+				counts[ent.Tribe]++
+			}
+			if v := w.villages[y][x]; v != nil {
+				farms[v.Tribe] += FarmFood * int64(v.Level)
+			}
+		}
+	}
+	w.eachPassenger(func(ent *Entity) {
+		counts[ent.Tribe]++
+	})
+
+	shortfall := make(map[uint8]float64) // Fraction of the meal the tribe couldn't pay for
+	for _, tribe := range w.sortedTribeIDs() {
+		res := w.resources[tribe]
+		if res == nil {
+			res = &TribeResources{}
+			w.resources[tribe] = res
+		}
+
+		res.Food += farms[tribe]
+		need := counts[tribe] * FoodPerEntity
+		if res.Food >= need {
+			res.Food -= need
+		} else {
+			shortfall[tribe] = float64(need-res.Food) / float64(need)
+			res.Food = 0
+		}
+	}
+
+	// True if ent starved to death
+	starves := func(ent *Entity) bool {
+		if short := shortfall[ent.Tribe]; short == 0 || w.rng.Float64() >= short {
+			if heal {
+				ent.Health += StarvationDamage
+				if ent.Health > ent.MaxHealth() {
+					ent.Health = ent.MaxHealth()
+				}
+			}
+			return false
+		}
+
+		ent.Health -= StarvationDamage
+		return ent.Health <= 0
+	}
+
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			ent := ents[y][x]
+			if ent == nil {
+				continue
+			}
+
+			if starves(ent) {
+				deaths = append(deaths, death{x, y, ent.Tribe, ent.Leader})
+				ents[y][x] = nil
+				w.lastReprodTick[y][x] = 0
+			}
+		}
+	}
+
+	return w.cullPassengers(deaths, starves)
+}
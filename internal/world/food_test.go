@@ -0,0 +1,119 @@
+package world
+
+import (
+	"strings"
+	"testing"
+)
+
+// Peace ticks up to and including the next meal
+func runToMeal(w *World) {
+	for {
+		w.PreWarUpdate()
+		if w.tickCount%MealTicks == 0 {
+			return
+		}
+	}
+}
+
+func TestPickBerriesOncePerRegrow(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr", "...")
+	w.Terrain[1] = uint8(TerrainBerries)
+	w.Entities[0][1] = &Entity{Health: 100, Tribe: 1, ID: 1, Rank: RankBase}
+	w.EntityStats.MoveChance = 0
+	w.resources[1] = &TribeResources{}
+
+	w.PreWarUpdate()
+	if got := w.resources[1].Food; got != BerryFood {
+		t.Fatalf("food %d after picking a bush, want %d", got, BerryFood)
+	}
+
+	w.PreWarUpdate()
+	if got := w.resources[1].Food; got != BerryFood {
+		t.Fatalf("food %d after picking a bare bush, want %d", got, BerryFood)
+	}
+}
+
+func TestMealEatsPerEntity(t *testing.T) {
+	w := newTestWorld(t, 1, "rrrrr", "1.1.1")
+	w.EntityStats.MoveChance = 0
+	w.resources[1] = &TribeResources{Food: 10}
+
+	runToMeal(w)
+	if got, want := w.resources[1].Food, 10-3*FoodPerEntity; got != want {
+		t.Fatalf("food %d after a meal for 3, want %d", got, want)
+	}
+}
+
+func TestEmptyLarderStopsReproduction(t *testing.T) {
+	w := breedingWorld(t)
+	w.resources[1].Food = 0
+	w.villages[0][2] = &Village{Tribe: 1, Level: 1}
+
+	// Stop before the first meal, the farm would refill the larder
+	for i := 0; i < MealTicks-1; i++ {
+		w.PreWarUpdate()
+	}
+	if n := len(entityPositions(w)); n != 1 {
+		t.Fatalf("%d entities with no food, want 1", n)
+	}
+}
+
+func TestStarvationKills(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr", ".1.")
+	w.EntityStats.MoveChance = 0
+	ent := w.Entities[0][1]
+
+	runToMeal(w)
+	if ent.Health != 100-StarvationDamage {
+		t.Fatalf("health %d after a missed meal, want %d", ent.Health, 100-StarvationDamage)
+	}
+
+	for i := 0; i < 100/StarvationDamage; i++ {
+		runToMeal(w)
+	}
+	if len(entityPositions(w)) != 0 {
+		t.Fatalf("entity still alive at health %d after starving", ent.Health)
+	}
+}
+
+func TestUnitsPlacedAfterResetEat(t *testing.T) {
+	w := newTestWorld(t, 1, strings.Repeat("r", 30), "")
+	w.Reset()
+	w.EntityStats.MoveChance = 0
+	w.EntityStats.ReproductionRate = 0
+	for x := 0; x < 30; x++ { // More mouths than the starting village's farm feeds
+		if !w.PlaceEntity(x, 0, 3) {
+			t.Fatalf("placing at %d failed", x)
+		}
+	}
+
+	runToMeal(w)
+	for ent, pos := range entityPositions(w) {
+		if ent.Health != 100 {
+			t.Fatalf("unit at %v has %d health after the first meal, want 100", pos, ent.Health)
+		}
+	}
+}
+
+// Armies eat too, an empty larder starves them mid-war
+func TestWarMealsStarve(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr^bbb", ".1...2.")
+	w.EntityStats.MoveChance = 0
+	w.resources[1] = &TribeResources{}
+	w.resources[2] = &TribeResources{Food: 10}
+	hungry, fed := w.Entities[0][1], w.Entities[0][5]
+	w.StartWar()
+
+	for w.tickCount%MealTicks != 0 || w.tickCount == 0 {
+		w.Update()
+	}
+	if hungry.Health != 100-StarvationDamage {
+		t.Fatalf("health %d after a missed war meal, want %d", hungry.Health, 100-StarvationDamage)
+	}
+	if fed.Health != 100 {
+		t.Fatalf("fed unit at %d health, want 100", fed.Health)
+	}
+	if got := w.resources[2].Food; got != 10-FoodPerEntity {
+		t.Fatalf("food %d after a war meal for 1, want %d", got, 10-FoodPerEntity)
+	}
+}
@@ -0,0 +1,218 @@
+package world
+
+import "fmt"
+
+// God powers, cast by players on a cell with a radius up to MaxPowerRadius.
+// A meteor kills everything in reach and leaves empty craters, an earthquake
+// throws flat land up into rocks and hills, lightning strikes a single cell
+// (setting a tree there alight), plague sickens every unit it reaches and
+// fire lights every tree in reach (see fire.go). Usable in peace and war
+const (
+	MaxPowerRadius = 8
+
+	QuakeDamage      = 20
+	QuakeRockChance  = 0.4 // Flat cell to rocks, the next QuakeHillChance to hills
+	QuakeHillChance  = 0.4
+	LightningDamage  = 75
+	PlagueDamage     = 40
+	PlagueMoraleLoss = 20
+)
+
+type GodPower uint8
+
+const (
+	PowerMeteor GodPower = iota
+	PowerEarthquake
+	PowerLightning
+	PowerPlague
+	PowerFire
+)
+
+func (p GodPower) String() string {
+	switch p {
+	case PowerMeteor:
+		return "meteor"
+
+	case PowerEarthquake:
+		return "earthquake"
+
+	case PowerLightning:
+		return "lightning"
+
+	case PowerPlague:
+		return "plague"
+
+	case PowerFire:
+		return "fire"
+
+	default:
+		return "unknown"
+	}
+}
+
+func ParseGodPower(s string) (GodPower, bool) {
+	for _, p := range []GodPower{PowerMeteor, PowerEarthquake, PowerLightning, PowerPlague, PowerFire} {
+		if p.String() == s {
+			return p, true
+		}
+	}
+
+	return 0, false
+}
+
+// Sent to every client when a power lands so they can animate it
+type PowerEvent struct {
+	Action string `json:"action"` // Always "god_power"
+	Power  string `json:"power"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	Radius int    `json:"radius"` // 0 for lightning, whatever was asked
+	Killed int    `json:"killed"`
+	Tick   int64  `json:"tick"`
+}
+
+// Casts p centred on (x, y). Fails on a cell off the map or a radius outside
+// 0..MaxPowerRadius
+func (w *World) UseGodPower(p GodPower, x, y, radius int) (PowerEvent, error) {
+	if radius < 0 || radius > MaxPowerRadius {
+		return PowerEvent{}, fmt.Errorf("radius %d out of range (0..%d)", radius, MaxPowerRadius)
+	}
+
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+
+	if x < 0 || x >= w.Width || y < 0 || y >= w.Height {
+		return PowerEvent{}, fmt.Errorf("cell (%d,%d) is off the %dx%d map", x, y, w.Width, w.Height)
+	}
+	if p == PowerLightning {
+		radius = 0
+	}
+
+	var deaths []death
+	w.eachInRadius(x, y, radius, func(cx, cy int) {
+		switch p {
+		case PowerMeteor:
+			deaths = w.meteorStrike(cx, cy, deaths)
+
+		case PowerEarthquake:
+			deaths = w.quake(cx, cy, deaths)
+
+		case PowerLightning:
+			deaths = w.hurt(w.Entities, cx, cy, LightningDamage, deaths)
+			w.ignite(cx, cy)
+
+		case PowerPlague:
+			if ent := w.Entities[cy][cx]; ent != nil {
+				ent.Morale -= PlagueMoraleLoss
+				if ent.Morale < 0 {
+					ent.Morale = 0
+				}
+			}
+			deaths = w.hurt(w.Entities, cx, cy, PlagueDamage, deaths)
+
+		case PowerFire:
+			w.ignite(cx, cy)
+		}
+	})
+	w.mournLeaders(w.Entities, deaths)
+
+	// Craters, new rocks and fires change the routes
+	w.dropFlowFields()
+
+	return PowerEvent{
+		Action: "god_power",
+		Power:  p.String(),
+		X:      x,
+		Y:      y,
+		Radius: radius,
+		Killed: len(deaths),
+		Tick:   w.tickCount,
+	}, nil
+}
+
+// Calls fn for every cell on the map within radius of (x, y), caller holds Mu
+func (w *World) eachInRadius(x, y, radius int, fn func(cx, cy int)) {
+	for cy := y - radius; cy <= y+radius; cy++ {
+		for cx := x - radius; cx <= x+radius; cx++ {
+			dx, dy := cx-x, cy-y
+			if cx < 0 || cx >= w.Width || cy < 0 || cy >= w.Height || dx*dx+dy*dy > radius*radius {
+				continue
+			}
+			fn(cx, cy)
+		}
+	}
+}
+
+// Takes dmg off the unit at (x, y) in ents, if any, and removes it if that
+// kills it. caller holds Mu
+func (w *World) hurt(ents [][]*Entity, x, y, dmg int, deaths []death) []death {
+	ent := ents[y][x]
+	if ent == nil {
+		return deaths
+	}
+
+	ent.Health -= dmg
+	if ent.Health > 0 {
+		return deaths
+	}
+	ents[y][x] = nil
+	w.lastReprodTick[y][x] = 0
+
+	return append(deaths, death{x, y, ent.Tribe, ent.Leader})
+}
+
+// Kills whatever is on (x, y), boats and their passengers included, razes
+// the village and leaves an empty crater. Water stays water. caller holds Mu
+func (w *World) meteorStrike(x, y int, deaths []death) []death {
+	if ent := w.Entities[y][x]; ent != nil {
+		deaths = append(deaths, death{x, y, ent.Tribe, ent.Leader})
+		w.Entities[y][x] = nil
+	}
+	if b := w.boats[y][x]; b != nil {
+		for _, ent := range b.Passengers {
+			deaths = append(deaths, death{x, y, ent.Tribe, ent.Leader})
+		}
+		w.boats[y][x] = nil
+	}
+	w.villages[y][x] = nil
+	w.lastReprodTick[y][x] = 0
+	w.lastClearedTick[y][x] = 0
+
+	if t := TerrainType(w.Terrain[y*w.Width+x]); t != TerrainWater && t != TerrainFord {
+		w.Terrain[y*w.Width+x] = uint8(TerrainEmpty)
+	}
+
+	return deaths
+}
+
+// Shakes (x, y): the unit there is hurt, a village drops a level (razed at
+// level 1) and bare flat land may heave up into rocks or hills. caller holds Mu
+func (w *World) quake(x, y int, deaths []death) []death {
+	deaths = w.hurt(w.Entities, x, y, QuakeDamage, deaths)
+
+	if v := w.villages[y][x]; v != nil {
+		v.Level--
+		if v.Level < 1 {
+			w.villages[y][x] = nil
+		}
+		return deaths // Rubble, not a new hill
+	}
+
+	t := TerrainType(w.Terrain[y*w.Width+x])
+	flat := t == TerrainEmpty
+	for _, home := range homeTerrains {
+		flat = flat || t == home
+	}
+	if !flat {
+		return deaths
+	}
+
+	roll := w.rng.Float64()
+	if roll < QuakeRockChance {
+		w.Terrain[y*w.Width+x] = uint8(TerrainRocks)
+	} else if roll < QuakeRockChance+QuakeHillChance {
+		w.Terrain[y*w.Width+x] = uint8(TerrainHills)
+	}
+
+	return deaths
+}
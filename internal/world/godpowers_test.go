@@ -0,0 +1,116 @@
+package world
+
+import "testing"
+
+func TestParseGodPowerRoundTrips(t *testing.T) {
+	for _, p := range []GodPower{PowerMeteor, PowerEarthquake, PowerLightning, PowerPlague} {
+		if got, ok := ParseGodPower(p.String()); !ok || got != p {
+			t.Fatalf("%q parsed as %v ok %v", p.String(), got, ok)
+		}
+	}
+	if _, ok := ParseGodPower("flood"); ok {
+		t.Fatal("unknown power parsed")
+	}
+}
+
+func TestGodPowerRejectsBadTargets(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr", "")
+	if _, err := w.UseGodPower(PowerMeteor, 1, 0, MaxPowerRadius+1); err == nil {
+		t.Fatal("radius over the max was accepted")
+	}
+	if _, err := w.UseGodPower(PowerMeteor, 3, 0, 1); err == nil {
+		t.Fatal("cell off the map was accepted")
+	}
+}
+
+func TestMeteorLeavesCrater(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rTr~bb
+`, `
+11...2
+`)
+	w.villages[0][2] = &Village{Tribe: 1, Level: 2}
+
+	ev, err := w.UseGodPower(PowerMeteor, 2, 0, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Killed != 2 || w.Entities[0][0] != nil || w.Entities[0][1] != nil {
+		t.Fatalf("killed %d, want both tribe 1 units", ev.Killed)
+	}
+	if w.Entities[0][5] == nil {
+		t.Fatal("unit outside the radius died")
+	}
+	if w.villages[0][2] != nil {
+		t.Fatal("village survived the meteor")
+	}
+	for x, want := range []TerrainType{TerrainEmpty, TerrainEmpty, TerrainEmpty, TerrainWater, TerrainEmpty, TerrainBlue} {
+		if got := TerrainType(w.Terrain[x]); got != want {
+			t.Fatalf("cell %d is %v, want %v", x, got, want)
+		}
+	}
+}
+
+func TestEarthquakeRaisesLandAndShakesVillages(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rrrrrrr
+rrrrrrr
+rrrrrrr
+`, "")
+	w.villages[1][3] = &Village{Tribe: 1, Level: 2}
+
+	if _, err := w.UseGodPower(PowerEarthquake, 3, 1, 3); err != nil {
+		t.Fatal(err)
+	}
+	if v := w.villages[1][3]; v == nil || v.Level != 1 {
+		t.Fatal("village didn't drop to level 1")
+	}
+	if TerrainType(w.Terrain[1*w.Width+3]) != TerrainRed {
+		t.Fatal("village cell heaved up")
+	}
+
+	raised := 0
+	for _, c := range w.Terrain {
+		if tt := TerrainType(c); tt == TerrainRocks || tt == TerrainHills {
+			raised++
+		}
+	}
+	if raised == 0 {
+		t.Fatal("quake raised no rocks or hills")
+	}
+}
+
+func TestLightningHitsOneCell(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr", "111")
+
+	ev, err := w.UseGodPower(PowerLightning, 1, 0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Radius != 0 {
+		t.Fatalf("radius %d, want 0", ev.Radius)
+	}
+	if got := w.Entities[0][1].Health; got != 100-LightningDamage {
+		t.Fatalf("struck unit has %d health, want %d", got, 100-LightningDamage)
+	}
+	if w.Entities[0][0].Health != 100 || w.Entities[0][2].Health != 100 {
+		t.Fatal("lightning hit a neighbour")
+	}
+}
+
+func TestPlagueSickensUnits(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr", "11.")
+	w.Entities[0][1].Health = PlagueDamage
+
+	ev, err := w.UseGodPower(PowerPlague, 0, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Killed != 1 || w.Entities[0][1] != nil {
+		t.Fatal("weak unit survived the plague")
+	}
+	ent := w.Entities[0][0]
+	if ent.Health != 100-PlagueDamage || ent.Morale != MaxMorale-PlagueMoraleLoss {
+		t.Fatalf("health %d morale %d after plague", ent.Health, ent.Morale)
+	}
+}
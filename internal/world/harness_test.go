@@ -0,0 +1,180 @@
+package world
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Terrain legend for test layouts. '#' is an impassable wall (a code
+// IsPassable doesn't know), handy for boxing entities in
+var layoutTerrain = map[rune]TerrainType{
+	'.': TerrainEmpty,
+	'r': TerrainRed,
+	'b': TerrainBlue,
+	'y': TerrainYellow,
+	'g': TerrainGreen,
+	'|': TerrainBorder,
+	'T': TerrainTrees,
+	'^': TerrainRocks,
+	'h': TerrainHills,
+	'~': TerrainWater,
+	'=': TerrainFord,
+	'#': TerrainType(255),
+}
+
+// Tribes for test worlds, no racial bonuses so numbers are easy to reason about
+func testTribes() map[uint8]TribeConfig {
+	return map[uint8]TribeConfig{
+		1: {HomeTerrain: TerrainRed, EntityVizCode: 3, Starters: 20, Name: "Red"},
+		2: {HomeTerrain: TerrainBlue, EntityVizCode: 5, Starters: 20, Name: "Blue"},
+		3: {HomeTerrain: TerrainYellow, EntityVizCode: 11, Starters: 20, Name: "Yellow"},
+		4: {HomeTerrain: TerrainGreen, EntityVizCode: 12, Starters: 20, Name: "Green"},
+	}
+}
+
+func layoutRows(t *testing.T, layout string) []string {
+	t.Helper()
+
+	rows := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(layout), "\n") {
+		rows = append(rows, strings.TrimSpace(line))
+	}
+	for _, row := range rows {
+		if len(row) != len(rows[0]) {
+			t.Fatalf("ragged layout row %q", row)
+		}
+	}
+
+	return rows
+}
+
+// Builds a seeded world from ASCII layouts. terrain uses layoutTerrain,
+// entities has a tribe digit where an entity stands, anything else is no
+// entity (empty string for none). Same seed + layout = same run
+func newTestWorld(t *testing.T, seed int64, terrain, entities string) *World {
+	t.Helper()
+
+	rows := layoutRows(t, terrain)
+	w := NewWithSeed(seed)
+	w.allocLayers(len(rows[0]), len(rows))
+	w.Tribes = testTribes()
+
+	for y, row := range rows {
+		for x, c := range row {
+			tt, ok := layoutTerrain[c]
+			if !ok {
+				t.Fatalf("unknown terrain %q at (%d,%d)", c, x, y)
+			}
+			w.Terrain[y*w.Width+x] = uint8(tt)
+		}
+	}
+
+	if entities == "" {
+		return w
+	}
+
+	entRows := layoutRows(t, entities)
+	if len(entRows) != w.Height || len(entRows[0]) != w.Width {
+		t.Fatalf("entity layout is %dx%d, terrain is %dx%d", len(entRows[0]), len(entRows), w.Width, w.Height)
+	}
+
+	for y, row := range entRows {
+		for x, c := range row {
+			if c < '0' || c > '9' {
+				continue
+			}
+			tribe := uint8(c - '0')
+			cfg, ok := w.Tribes[tribe]
+			if !ok {
+				t.Fatalf("unknown tribe %q at (%d,%d)", c, x, y)
+			}
+
+			next := w.nextEntityID[tribe]
+			if next == nil {
+				next = new(uint32)
+				w.nextEntityID[tribe] = next
+			}
+			*next++
+			w.Entities[y][x] = &Entity{
+				Health:  100,
+				Tribe:   tribe,
+				ID:      *next,
+				Evasion: cfg.BaseEvasion,
+				Rank:    RankBase,
+				Morale:  MaxMorale,
+			}
+		}
+	}
+
+	return w
+}
+
+// Seeded world on a real map builder, for property checks over many seeds
+func newMapWorld(t *testing.T, seed int64, mapName string, width, height int) *World {
+	t.Helper()
+
+	w := NewWithSeed(seed)
+	if err := w.Resize(width, height); err != nil {
+		t.Fatal(err)
+	}
+	w.InitMap(mapName)
+	return w
+}
+
+// Snapshots w into a fresh world and restores it there
+func roundTrip(t *testing.T, w *World) *World {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := w.Snapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := NewWithSeed(1)
+	if err := restored.Restore(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	return restored
+}
+
+// Every live entity keyed by pointer, with its position
+func entityPositions(w *World) map[*Entity][2]int {
+	pos := make(map[*Entity][2]int)
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if ent := w.Entities[y][x]; ent != nil {
+				pos[ent] = [2]int{x, y}
+			}
+		}
+	}
+
+	return pos
+}
+
+// Fails if any entity sits in two cells at once (the grid itself can't hold
+// two in one cell, so a duplicate pointer is how a bad move shows up)
+func assertOneCellPerEntity(t *testing.T, w *World) {
+	t.Helper()
+
+	seen := make(map[*Entity][2]int)
+	ids := make(map[[2]uint32][2]int)
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			ent := w.Entities[y][x]
+			if ent == nil {
+				continue
+			}
+			if at, ok := seen[ent]; ok {
+				t.Fatalf("tick %d: entity %d of tribe %d at both (%d,%d) and (%d,%d)", w.tickCount, ent.ID, ent.Tribe, at[0], at[1], x, y)
+			}
+			seen[ent] = [2]int{x, y}
+
+			key := [2]uint32{uint32(ent.Tribe), ent.ID}
+			if at, ok := ids[key]; ok {
+				t.Fatalf("tick %d: tribe %d id %d used at both (%d,%d) and (%d,%d)", w.tickCount, ent.Tribe, ent.ID, at[0], at[1], x, y)
+			}
+			ids[key] = [2]int{x, y}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package world
+
+// Leaders, at most one per tribe. In peace a tribe buys one for LeaderCost,
+// in war its highest-ranked veteran (RankSuper or better) steps up, no sooner
+// than LeaderCooldown ticks after the last leader fell. A leader has LeaderHealth, and its own units within
+// LeaderRadius hit harder, take less and heal faster. Losing it shakes the
+// morale of the whole tribe
+const (
+	LeaderVizBase    uint8 = 32 // Grid code sent to clients is this plus the tribe, see LeaderVizCode
+	LeaderHealth           = 300
+	LeaderRadius           = 3 // Chebyshev distance of the aura
+	LeaderDamage           = 2 // Aura bonuses
+	LeaderArmor            = 1
+	LeaderRegen            = 2   // Extra health per war tick
+	LeaderDeathShock       = 25  // Morale every unit of the tribe loses when it falls
+	LeaderCooldown         = 100 // War ticks before the next one steps up
+)
+
+var LeaderCost = Cost{Wood: 10, Food: 30}
+
+// Grid code for a leader of tribe, one per tribe so spectators can tell
+// whose commander it is
+func LeaderVizCode(tribe uint8) uint8 {
+	return LeaderVizBase + tribe
+}
+
+func (e *Entity) MaxHealth() int {
+	if e.Leader {
+		return LeaderHealth
+	}
+
+	return 100
+}
+
+// Aura bonuses, 0 outside a leader's reach
+func (e *Entity) auraDamage() int {
+	if e.inspired {
+		return LeaderDamage
+	}
+
+	return 0
+}
+
+func (e *Entity) auraArmor() int {
+	if e.inspired {
+		return LeaderArmor
+	}
+
+	return 0
+}
+
+// True if a should lead before b: higher rank, then more kills
+func outranks(a, b *Entity) bool {
+	if a.Rank != b.Rank {
+		return a.Rank > b.Rank
+	}
+
+	return a.Kills > b.Kills
+}
+
+// Gives leaderless tribes a leader, caller holds Mu. In peace it's bought by
+// tribes that aren't saving, in war a veteran takes it for free once the
+// cooldown is over
+func (w *World) crownLeaders(ents [][]*Entity, war bool, saving map[uint8]bool) {
+	led := make(map[uint8]bool)
+	best := make(map[uint8]*Entity)
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			ent := ents[y][x]
+			if ent == nil {
+				continue
+			}
+			if ent.Leader {
+				led[ent.Tribe] = true
+			} else if b := best[ent.Tribe]; b == nil || outranks(ent, b) {
+				best[ent.Tribe] = ent
+			}
+		}
+	}
+	w.eachPassenger(func(ent *Entity) {
+		led[ent.Tribe] = led[ent.Tribe] || ent.Leader // At sea, still leading
+	})
+
+	for _, tribe := range w.sortedTribeIDs() {
+		pick := best[tribe]
+		if led[tribe] || pick == nil {
+			continue
+		}
+
+		if war {
+			if pick.Rank < RankSuper {
+				continue
+			}
+			if fell, ok := w.leaderFell[tribe]; ok && w.tickCount-fell < LeaderCooldown {
+				continue
+			}
+		} else {
+			res := w.resources[tribe]
+			if saving[tribe] || res == nil || !res.canPay(LeaderCost) {
+				continue
+			}
+			res.pay(LeaderCost)
+		}
+
+		pick.Leader = true
+		pick.Health = LeaderHealth
+	}
+}
+
+// Marks every unit within LeaderRadius of its own tribe's leader as inspired,
+// caller holds Mu
+func (w *World) applyAuras(ents [][]*Entity) {
+	type pos struct{ x, y int }
+	leaders := make(map[uint8]pos)
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if ent := ents[y][x]; ent != nil && ent.Leader {
+				leaders[ent.Tribe] = pos{x, y}
+			}
+		}
+	}
+
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			ent := ents[y][x]
+			if ent == nil {
+				continue
+			}
+			l, ok := leaders[ent.Tribe]
+			ent.inspired = ok && l.x-x <= LeaderRadius && x-l.x <= LeaderRadius && l.y-y <= LeaderRadius && y-l.y <= LeaderRadius
+		}
+	}
+}
+
+// Fallen leaders from this tick's deaths shake their tribe and start the
+// cooldown on a successor, caller holds Mu
+func (w *World) mournLeaders(ents [][]*Entity, deaths []death) {
+	for _, d := range deaths {
+		if !d.leader {
+			continue
+		}
+		w.leaderFell[d.tribe] = w.tickCount
+
+		for y := 0; y < w.Height; y++ {
+			for x := 0; x < w.Width; x++ {
+				if ent := ents[y][x]; ent != nil && ent.Tribe == d.tribe {
+					ent.Morale -= LeaderDeathShock
+					if ent.Morale < 0 {
+						ent.Morale = 0
+					}
+				}
+			}
+		}
+	}
+}
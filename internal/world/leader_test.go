@@ -0,0 +1,90 @@
+package world
+
+import "testing"
+
+func TestLeaderAuraReach(t *testing.T) {
+	w := newTestWorld(t, 1, "rrrrr", "1..11")
+	leader, near, far := w.Entities[0][0], w.Entities[0][3], w.Entities[0][4]
+	leader.Leader = true
+
+	w.applyAuras(w.Entities)
+	if !near.inspired || far.inspired {
+		t.Fatalf("inspired near %v far %v, want only the unit within %d", near.inspired, far.inspired, LeaderRadius)
+	}
+
+	plain := &Entity{Tribe: 1}
+	if near.TotalDamage(w)-plain.TotalDamage(w) != LeaderDamage || near.TotalArmor(w)-plain.TotalArmor(w) != LeaderArmor {
+		t.Fatal("aura bonuses missing from TotalDamage/TotalArmor")
+	}
+}
+
+func TestBuyLeaderInPeace(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr", "11.")
+	w.resources[1] = &TribeResources{Wood: LeaderCost.Wood, Food: LeaderCost.Food}
+
+	w.crownLeaders(w.Entities, false, map[uint8]bool{1: true})
+	if w.Entities[0][0].Leader || w.Entities[0][1].Leader {
+		t.Fatal("tribe saving for a village bought a leader")
+	}
+
+	w.crownLeaders(w.Entities, false, map[uint8]bool{})
+	leader := w.Entities[0][0]
+	if !leader.Leader || leader.Health != LeaderHealth || w.Entities[0][1].Leader {
+		t.Fatal("want exactly one leader at full leader health")
+	}
+	if res := w.resources[1]; res.Wood != 0 || res.Food != 0 {
+		t.Fatalf("resources %+v after buying a leader, want all spent", *res)
+	}
+}
+
+func TestVeteranStepsUpAfterCooldown(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr", "11.")
+	recruit, veteran := w.Entities[0][0], w.Entities[0][1]
+	veteran.Rank, veteran.Kills = RankSuper, RankSuper.KillsNeeded()
+	w.leaderFell[1] = 0
+	w.tickCount = LeaderCooldown - 1
+
+	w.crownLeaders(w.Entities, true, nil)
+	if veteran.Leader {
+		t.Fatal("veteran stepped up during the cooldown")
+	}
+
+	w.tickCount = LeaderCooldown
+	w.crownLeaders(w.Entities, true, nil)
+	if !veteran.Leader || recruit.Leader {
+		t.Fatal("veteran didn't step up after the cooldown")
+	}
+}
+
+func TestLeaderDeathShakesTribe(t *testing.T) {
+	w := newTestWorld(t, 1, "rrrrrrb", "1....12")
+	w.tickCount = 42
+
+	w.mournLeaders(w.Entities, []death{{x: 3, y: 0, tribe: 1, leader: true}})
+	if got := w.Entities[0][0].Morale; got != MaxMorale-LeaderDeathShock {
+		t.Fatalf("far unit morale %d, want %d", got, MaxMorale-LeaderDeathShock)
+	}
+	if got := w.Entities[0][6].Morale; got != MaxMorale {
+		t.Fatalf("enemy morale %d, want untouched", got)
+	}
+	if w.leaderFell[1] != 42 {
+		t.Fatalf("leader fell at %d, want 42", w.leaderFell[1])
+	}
+}
+
+func TestLeaderGridCode(t *testing.T) {
+	w := newTestWorld(t, 1, "rr", "11")
+	w.Entities[0][1].Leader = true
+
+	grid := w.GetGridCopy()
+	if grid[0] != 3 || grid[1] != LeaderVizCode(1) {
+		t.Fatalf("grid %v, want [3 %d]", grid, LeaderVizCode(1))
+	}
+
+	// Another tribe's leader renders differently
+	w.Entities[0][0].Tribe = 2
+	w.Entities[0][0].Leader = true
+	if grid := w.GetGridCopy(); grid[0] == grid[1] {
+		t.Fatalf("both tribes' leaders render as %d", grid[0])
+	}
+}
@@ -2,7 +2,9 @@ package world
 
 import (
 	"log"
+	"math"
 	"math/rand"
+	"sort"
 	"strconv"
 	"sync/atomic"
 )
@@ -41,12 +43,33 @@ func GetRandomTribeNames(count int) []string {
 func (w *World) ConvertBordersToTerrain() {
 	w.Mu.Lock()
 	defer w.Mu.Unlock()
+
+	flats := make(map[uint8]bool)
+	for _, t := range homeTerrains {
+		flats[uint8(t)] = true
+	}
 	
+	// Repeat until nothing changes, a border cell whose neighbours only became
+	// flat later in the same scan (e.g. a top-left corner of border) still goes
+	converted := 0
+	for {
+		n := w.convertBorderPass(flats)
+		if n == 0 {
+			break
+		}
+		converted += n
+	}
+	
+	log.Printf("Converted %d border cells to adjacent terrain", converted)
+}
+
+// One scan over the grid, returns how many border cells were converted
+func (w *World) convertBorderPass(flats map[uint8]bool) int {
 	converted := 0
 	
-	for y := 0; y < GridSize; y++ {
-		for x := 0; x < GridSize; x++ {
-			idx := y*GridSize + x
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			idx := y*w.Width + x
 			
 			// Only convert border cells
 			if w.Terrain[idx] != uint8(TerrainBorder) {
@@ -69,13 +92,12 @@ func (w *World) ConvertBordersToTerrain() {
 				ny := y + dir[1]
 				
 				// Bounds check
-				if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-					nidx := ny*GridSize + nx
+				if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+					nidx := ny*w.Width + nx
 					neighborTerrain := w.Terrain[nidx]
 					
 					// Count terrain types (1=red, 2=blue, 9=yellow, 10=green)
-					if neighborTerrain == 1 || neighborTerrain == 2 || 
-					   neighborTerrain == 9 || neighborTerrain == 10 {
+					if flats[neighborTerrain] {
 						adjacentTerrains[neighborTerrain]++
 					}
 				}
@@ -87,7 +109,8 @@ func (w *World) ConvertBordersToTerrain() {
 				var chosenTerrain uint8 = 1 // default to red if tie
 				
 				for terrain, count := range adjacentTerrains {
-					if count > maxCount {
+					// Lowest terrain code wins ties so seeded replays stay stable
+					if count > maxCount || (count == maxCount && terrain < chosenTerrain) {
 						maxCount = count
 						chosenTerrain = terrain
 					}
@@ -99,7 +122,70 @@ func (w *World) ConvertBordersToTerrain() {
 		}
 	}
 	
-	log.Printf("Converted %d border cells to adjacent terrain", converted)
+	return converted
+}
+
+// Scales a feature count tuned for the default 100x100 map to this world's area
+func scaledCount(w *World, n int) int {
+    scaled := n * w.Width * w.Height / (DefaultGridSize * DefaultGridSize)
+    if scaled < 1 {
+        return 1
+    }
+
+    return scaled
+}
+
+// Random draws per feature a placement loop gets to find home flat before it
+// gives up, so a map with little or none left can't spin forever
+const placementTries = 100
+
+// Random home-flat cell for the next feature, drawing until one turns up or the
+// loop's shared budget in tries runs out
+func randomFlatCell(w *World, isHomeFlat func(TerrainType) bool, tries *int) (int, int, bool) {
+    for *tries > 0 {
+        *tries--
+        cx, cy := w.rng.Intn(w.Width), w.rng.Intn(w.Height)
+        if isHomeFlat(TerrainType(w.Terrain[cy*w.Width + cx])) {
+            return cx, cy, true
+        }
+    }
+
+    return 0, 0, false
+}
+
+// Drops cfg.Starters fresh units at random on the tribe's home terrain,
+// warning about any that don't find a free cell in 1000 draws. caller holds Mu
+func (w *World) placeStarters(tribe uint8, cfg TribeConfig) {
+    for i := 0; i < cfg.Starters; i++ {
+        placed := false
+        for attempts := 0; attempts < 1000; attempts++ {
+            x, y := w.rng.Intn(w.Width), w.rng.Intn(w.Height)
+            if w.Entities[y][x] == nil && TerrainType(w.Terrain[y*w.Width + x]) == cfg.HomeTerrain {
+                counter := w.nextEntityID[tribe]
+                if counter == nil {
+                    counter = new(uint32)
+                    w.nextEntityID[tribe] = counter
+                }
+                id := atomic.AddUint32(counter, 1)
+                w.Entities[y][x] = &Entity{
+                    Health:  100,
+                    Tribe:   tribe,
+                    Weapon:  WeaponNone,
+                    Armor:   ArmorNone,
+                    ID:      id,
+                    Evasion: cfg.BaseEvasion,
+                    Rank:    RankBase,
+                    Morale:  MaxMorale,
+                    Born:    w.starterBorn(cfg),
+                }
+                placed = true
+                break
+            }
+        }
+        if !placed {
+            log.Printf("Warning: Could not place starter for tribe %d (%s)", tribe, cfg.Name)
+        }
+    }
 }
 
 // Classic left/right vertical split (exact copy of your old InitSplitHalves logic)
@@ -108,28 +194,8 @@ func InitVerticalSplit(w *World) {
         w.Terrain[i] = 0
     }
 
-    // Set active tribes config
-    w.Tribes = map[uint8]TribeConfig{
-        1: {
-            HomeTerrain: TerrainRed, 
-            EntityVizCode: 3, 
-            Starters: 20,
-            Name: "Nomads",
-            DamageBonus: 2, // Orcs get 2 dmg racial
-            BaseEvasion: 0.0, // No evasion
-            DefenseBonus: 0, // No defense racial
-        },
-
-        2: {
-            HomeTerrain: TerrainBlue, 
-            EntityVizCode: 5, 
-            Starters: 20,
-            Name: "Wanderers",
-            DamageBonus: 0, // no damage racial
-            BaseEvasion: 0.22, // 22% evasion
-            DefenseBonus: 0, // no defense racial
-        },
-    }
+    // Set active tribes config (lineup and racial stats come from the races file)
+    w.Tribes = Races().mapTribes("vertical")
 
     // randomNames := GetRandomTribeNames(2)
     // w.Tribes = map[uint8]TribeConfig{
@@ -148,19 +214,20 @@ func InitVerticalSplit(w *World) {
     // }
 
     // Paint base terrain
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
-            if x < 50 {
-                w.Terrain[y*GridSize + x] = uint8(TerrainRed)
-            } else if x > 50 {
-                w.Terrain[y*GridSize + x] = uint8(TerrainBlue)
+    midX := w.Width / 2
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
+            if x < midX {
+                w.Terrain[y*w.Width + x] = uint8(TerrainRed)
+            } else if x > midX {
+                w.Terrain[y*w.Width + x] = uint8(TerrainBlue)
             }
         }
     }
 
     // Center green border
-    for y := 0; y < GridSize; y++ {
-        w.Terrain[y*GridSize + 50] = uint8(TerrainBorder)
+    for y := 0; y < w.Height; y++ {
+        w.Terrain[y*w.Width + midX] = uint8(TerrainBorder)
     }
 
     // Helper: list of all home flats for this map
@@ -174,20 +241,18 @@ func InitVerticalSplit(w *World) {
     }
 
     // === Trees (25 forests, 12-20 trees each) ===
-    for i := 0; i < 25; i++ {
-        cx, cy := rand.Intn(GridSize), rand.Intn(GridSize)
-        idx := cy*GridSize + cx
-        current := TerrainType(w.Terrain[idx])
-        if w.Terrain[idx] == uint8(TerrainBorder) || !isHomeFlat(current) {
-            i-- // Retry
-            continue
+    tries := placementTries * scaledCount(w, 25)
+    for i := 0; i < scaledCount(w, 25); i++ {
+        cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+        if !ok {
+            break // No flat left to put it on
         }
 
-        treesInCluster := 12 + rand.Intn(9)
+        treesInCluster := 12 + w.rng.Intn(9)
         for j := 0; j < treesInCluster; j++ {
-            nx, ny := cx + rand.Intn(15)-7, cy + rand.Intn(15)-7
-            if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-                nidx := ny*GridSize + nx
+            nx, ny := cx + w.rng.Intn(15)-7, cy + w.rng.Intn(15)-7
+            if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+                nidx := ny*w.Width + nx
                 if w.Terrain[nidx] != uint8(TerrainBorder) {
                     if isHomeFlat(TerrainType(w.Terrain[nidx])) {
                         w.Terrain[nidx] = uint8(TerrainTrees)
@@ -198,20 +263,18 @@ func InitVerticalSplit(w *World) {
     }
 
     // === Rocks (15 clusters, 8-15 each, tighter) ===
-    for i := 0; i < 15; i++ {
-        cx, cy := rand.Intn(GridSize), rand.Intn(GridSize)
-        idx := cy*GridSize + cx
-        current := TerrainType(w.Terrain[idx])
-        if w.Terrain[idx] == uint8(TerrainBorder) || !isHomeFlat(current) {
-            i--
-            continue
+    tries = placementTries * scaledCount(w, 15)
+    for i := 0; i < scaledCount(w, 15); i++ {
+        cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+        if !ok {
+            break // No flat left to put it on
         }
 
-        rocksInCluster := 8 + rand.Intn(8)
+        rocksInCluster := 8 + w.rng.Intn(8)
         for j := 0; j < rocksInCluster; j++ {
-            nx, ny := cx + rand.Intn(11)-5, cy + rand.Intn(11)-5
-            if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-                nidx := ny*GridSize + nx
+            nx, ny := cx + w.rng.Intn(11)-5, cy + w.rng.Intn(11)-5
+            if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+                nidx := ny*w.Width + nx
                 if w.Terrain[nidx] != uint8(TerrainBorder) {
                     if isHomeFlat(TerrainType(w.Terrain[nidx])) {
                         w.Terrain[nidx] = uint8(TerrainRocks)
@@ -222,20 +285,18 @@ func InitVerticalSplit(w *World) {
     }
 
     // === Hills (20 areas, 20-40 each, larger) ===
-    for i := 0; i < 20; i++ {
-        cx, cy := rand.Intn(GridSize), rand.Intn(GridSize)
-        idx := cy*GridSize + cx
-        current := TerrainType(w.Terrain[idx])
-        if w.Terrain[idx] == uint8(TerrainBorder) || !isHomeFlat(current) {
-            i--
-            continue
+    tries = placementTries * scaledCount(w, 20)
+    for i := 0; i < scaledCount(w, 20); i++ {
+        cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+        if !ok {
+            break // No flat left to put it on
         }
 
-        hillsInArea := 20 + rand.Intn(21)
+        hillsInArea := 20 + w.rng.Intn(21)
         for j := 0; j < hillsInArea; j++ {
-            nx, ny := cx + rand.Intn(21)-10, cy + rand.Intn(21)-10
-            if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-                nidx := ny*GridSize + nx
+            nx, ny := cx + w.rng.Intn(21)-10, cy + w.rng.Intn(21)-10
+            if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+                nidx := ny*w.Width + nx
                 if w.Terrain[nidx] != uint8(TerrainBorder) {
                     if isHomeFlat(TerrainType(w.Terrain[nidx])) {
                         w.Terrain[nidx] = uint8(TerrainHills)
@@ -245,37 +306,15 @@ func InitVerticalSplit(w *World) {
         }
     }
 
-    // Starters (generic)
-    for tribe, cfg := range w.Tribes {
-        for i := 0; i < cfg.Starters; i++ {
-            placed := false
-            for attempts := 0; attempts < 1000; attempts++ {
-                x, y := rand.Intn(GridSize), rand.Intn(GridSize)
-                if w.Entities[y][x] == nil && TerrainType(w.Terrain[y*GridSize + x]) == cfg.HomeTerrain {
-                    counter := w.nextEntityID[tribe]
-                    if counter == nil {
-                        counter = new(uint32)
-                        w.nextEntityID[tribe] = counter
-                    }
-                    id := atomic.AddUint32(counter, 1)
-                    w.Entities[y][x] = &Entity{
-                        Health: 100, 
-                        Tribe: tribe, 
-                        Weapon: WeaponNone, 
-                        Armor: ArmorNone, 
-                        ID: id,
-                        Evasion: cfg.BaseEvasion,
-                        Rank: RankBase,
-                    }
-                    placed = true
-                    break
-                }
-            }
+    // === Berry bushes (20 patches, 5-9 each) ===
+    placeBerryPatches(w, isHomeFlat)
 
-            if !placed {
-                log.Printf("Warning: Could not place starter for tribe %d (vertical map)", tribe)
-            }
-        }
+    // === Ore (12 veins, 5-9 each) ===
+    placeOreVeins(w, isHomeFlat)
+
+    // Starters (generic)
+    for _, tribe := range w.sortedTribeIDs() {
+        w.placeStarters(tribe, w.Tribes[tribe])
     }
 }
 
@@ -288,71 +327,34 @@ func InitFourQuadrants(w *World) {
         w.Terrain[i] = 0
     }
 
-    w.Tribes = map[uint8]TribeConfig{
-        1: {
-            HomeTerrain: TerrainRed,
-            EntityVizCode: 3,
-            Starters: 20,
-            Name: "Wanderers",
-            DamageBonus: 0,
-            BaseEvasion: 0.22,
-            DefenseBonus: 0, 
-        },
-
-        2: {
-            HomeTerrain: TerrainBlue,
-            EntityVizCode: 5,
-            Starters: 20,
-            Name: "Norsca",
-            DamageBonus: 0,
-            BaseEvasion: 0.00,
-            DefenseBonus: 2, 
-        },
-
-        3: {
-            HomeTerrain: TerrainYellow,
-            EntityVizCode: 11,
-            Starters: 20,
-            Name: "Nomads",
-            DamageBonus: 2,
-            BaseEvasion: 0.0,
-            DefenseBonus: 0,
-        },
-
-        4: {
-            HomeTerrain: TerrainGreen,
-            EntityVizCode: 12,
-            Starters: 20,
-            Name: "Sylvania",
-            DamageBonus: 1,
-            BaseEvasion: 0.12,
-            DefenseBonus: 0, 
-        },
-    }
+    w.Tribes = Races().mapTribes("fourquadrants")
     
     // Paint base terrain
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
-            if x < 50 && y < 50 {
+    midX, midY := w.Width / 2, w.Height / 2
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
+            if x < midX && y < midY {
                 // Top left
-                w.Terrain[y*GridSize + x] = uint8(TerrainRed)
-            } else if x > 50 && y < 50 {
+                w.Terrain[y*w.Width + x] = uint8(TerrainRed)
+            } else if x > midX && y < midY {
                 // Top right
-                w.Terrain[y*GridSize + x] = uint8(TerrainBlue)
-            } else if x < 50 && y > 50 {
+                w.Terrain[y*w.Width + x] = uint8(TerrainBlue)
+            } else if x < midX && y > midY {
                 // Bottm left
-                w.Terrain[y * GridSize + x] = uint8(TerrainYellow)
-            } else if x > 50 && y > 50 {
+                w.Terrain[y*w.Width + x] = uint8(TerrainYellow)
+            } else if x > midX && y > midY {
                 // Bottom right
-                w.Terrain[y * GridSize + x] = uint8(TerrainGreen)
+                w.Terrain[y*w.Width + x] = uint8(TerrainGreen)
             } 
         }
     }
 
     // Center border
-    for i := 0; i < GridSize; i++ {
-        w.Terrain[i * GridSize + 50] = uint8(TerrainBorder)
-        w.Terrain[50 * GridSize + i] = uint8(TerrainBorder)
+    for y := 0; y < w.Height; y++ {
+        w.Terrain[y*w.Width + midX] = uint8(TerrainBorder)
+    }
+    for x := 0; x < w.Width; x++ {
+        w.Terrain[midY*w.Width + x] = uint8(TerrainBorder)
     }
 
     homeFlats := make(map[TerrainType]bool)
@@ -364,20 +366,18 @@ func InitFourQuadrants(w *World) {
     }
 
     // === Trees (25 forests, 12-20 trees each) ===
-    for i := 0; i < 25; i++ {
-        cx, cy := rand.Intn(GridSize), rand.Intn(GridSize)
-        idx := cy*GridSize + cx
-        current := TerrainType(w.Terrain[idx])
-        if w.Terrain[idx] == uint8(TerrainBorder) || !isHomeFlat(current) {
-            i-- // Retry
-            continue
+    tries := placementTries * scaledCount(w, 25)
+    for i := 0; i < scaledCount(w, 25); i++ {
+        cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+        if !ok {
+            break // No flat left to put it on
         }
 
-        treesInCluster := 12 + rand.Intn(9)
+        treesInCluster := 12 + w.rng.Intn(9)
         for j := 0; j < treesInCluster; j++ {
-            nx, ny := cx + rand.Intn(15)-7, cy + rand.Intn(15)-7
-            if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-                nidx := ny*GridSize + nx
+            nx, ny := cx + w.rng.Intn(15)-7, cy + w.rng.Intn(15)-7
+            if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+                nidx := ny*w.Width + nx
                 if w.Terrain[nidx] != uint8(TerrainBorder) {
                     if isHomeFlat(TerrainType(w.Terrain[nidx])) {
                         w.Terrain[nidx] = uint8(TerrainTrees)
@@ -388,20 +388,18 @@ func InitFourQuadrants(w *World) {
     }
 
     // // === Rocks (15 clusters, 8-15 each, tighter) ===
-    for i := 0; i < 15; i++ {
-        cx, cy := rand.Intn(GridSize), rand.Intn(GridSize)
-        idx := cy*GridSize + cx
-        current := TerrainType(w.Terrain[idx])
-        if w.Terrain[idx] == uint8(TerrainBorder) || !isHomeFlat(current) {
-            i--
-            continue
+    tries = placementTries * scaledCount(w, 15)
+    for i := 0; i < scaledCount(w, 15); i++ {
+        cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+        if !ok {
+            break // No flat left to put it on
         }
 
-        rocksInCluster := 8 + rand.Intn(8)
+        rocksInCluster := 8 + w.rng.Intn(8)
         for j := 0; j < rocksInCluster; j++ {
-            nx, ny := cx + rand.Intn(11)-5, cy + rand.Intn(11)-5
-            if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-                nidx := ny*GridSize + nx
+            nx, ny := cx + w.rng.Intn(11)-5, cy + w.rng.Intn(11)-5
+            if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+                nidx := ny*w.Width + nx
                 if w.Terrain[nidx] != uint8(TerrainBorder) {
                     if isHomeFlat(TerrainType(w.Terrain[nidx])) {
                         w.Terrain[nidx] = uint8(TerrainRocks)
@@ -412,20 +410,18 @@ func InitFourQuadrants(w *World) {
     }
 
     // // === Hills (20 areas, 20-40 each, larger) ===
-    for i := 0; i < 20; i++ {
-        cx, cy := rand.Intn(GridSize), rand.Intn(GridSize)
-        idx := cy*GridSize + cx
-        current := TerrainType(w.Terrain[idx])
-        if w.Terrain[idx] == uint8(TerrainBorder) || !isHomeFlat(current) {
-            i--
-            continue
+    tries = placementTries * scaledCount(w, 20)
+    for i := 0; i < scaledCount(w, 20); i++ {
+        cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+        if !ok {
+            break // No flat left to put it on
         }
 
-        hillsInArea := 20 + rand.Intn(21)
+        hillsInArea := 20 + w.rng.Intn(21)
         for j := 0; j < hillsInArea; j++ {
-            nx, ny := cx + rand.Intn(21)-10, cy + rand.Intn(21)-10
-            if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-                nidx := ny*GridSize + nx
+            nx, ny := cx + w.rng.Intn(21)-10, cy + w.rng.Intn(21)-10
+            if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+                nidx := ny*w.Width + nx
                 if w.Terrain[nidx] != uint8(TerrainBorder) {
                     if isHomeFlat(TerrainType(w.Terrain[nidx])) {
                         w.Terrain[nidx] = uint8(TerrainHills)
@@ -435,35 +431,14 @@ func InitFourQuadrants(w *World) {
         }
     }
 
-    for tribe, cfg := range w.Tribes {
-        for i := 0; i < cfg.Starters; i++ {
-            placed := false
-            for attempts := 0; attempts < 1000; attempts++ {
-                x, y := rand.Intn(GridSize), rand.Intn(GridSize)
-                if w.Entities[y][x] == nil && TerrainType(w.Terrain[y*GridSize + x]) == cfg.HomeTerrain {
-                    counter := w.nextEntityID[tribe]
-                    if counter == nil {
-                        counter = new(uint32)
-                        w.nextEntityID[tribe] = counter
-                    }
-                    id := atomic.AddUint32(counter, 1)
-                    w.Entities[y][x] = &Entity{
-                        Health: 100,
-                        Tribe: tribe,
-                        Weapon: WeaponNone,
-                        Armor: ArmorNone,
-                        ID: id,
-                        Evasion: cfg.BaseEvasion,
-                        Rank: RankBase,
-                    }
-                    placed = true
-                    break
-                }
-            }
-            if !placed {
-                log.Printf("Warning: Could not place starter for tribe %d (north-south map)", tribe)
-            }
-        }
+    // === Berry bushes (20 patches, 5-9 each) ===
+    placeBerryPatches(w, isHomeFlat)
+
+    // === Ore (12 veins, 5-9 each) ===
+    placeOreVeins(w, isHomeFlat)
+
+    for _, tribe := range w.sortedTribeIDs() {
+        w.placeStarters(tribe, w.Tribes[tribe])
     }
 }
 
@@ -472,40 +447,21 @@ func InitNorthSouth(w *World) {
         w.Terrain[i] = 0
     }
 
-    w.Tribes = map[uint8]TribeConfig{
-        1: {
-            HomeTerrain: TerrainRed,
-            EntityVizCode: 3,
-            Starters: 20,
-            Name: "Norsca",
-            DamageBonus: 0, // No dmg bonus racial
-            BaseEvasion: 0, // No evasion bonus racial
-            DefenseBonus: 2, // Nords extra defense racial
-        },
-
-        2: {
-            HomeTerrain: TerrainBlue,
-            EntityVizCode: 5,
-            Starters: 20,
-            Name: "Sylvania",
-            DamageBonus: 1, // Vampires racial (+1 dmg)
-            BaseEvasion: 0.12, // Vampires racial (12% evasion)
-            DefenseBonus: 0, // Vampires no racial defense
-        },
-    }
-
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
-            if y < 50 {
-                w.Terrain[y*GridSize + x] = uint8(TerrainRed)
-            } else if y > 50 {
-                w.Terrain[y*GridSize + x] = uint8(TerrainBlue)
+    w.Tribes = Races().mapTribes("northsouth")
+
+    midY := w.Height / 2
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
+            if y < midY {
+                w.Terrain[y*w.Width + x] = uint8(TerrainRed)
+            } else if y > midY {
+                w.Terrain[y*w.Width + x] = uint8(TerrainBlue)
             }
         }
     }
 
-    for x := 0; x < GridSize; x++ {
-        w.Terrain[50 * GridSize + x] = uint8(TerrainBorder)
+    for x := 0; x < w.Width; x++ {
+        w.Terrain[midY*w.Width + x] = uint8(TerrainBorder)
     }
 
     homeFlats := make(map[TerrainType]bool)
@@ -517,20 +473,18 @@ func InitNorthSouth(w *World) {
     }
 
     // === Trees (25 forests, 12-20 trees each) ===
-    for i := 0; i < 25; i++ {
-        cx, cy := rand.Intn(GridSize), rand.Intn(GridSize)
-        idx := cy*GridSize + cx
-        current := TerrainType(w.Terrain[idx])
-        if w.Terrain[idx] == uint8(TerrainBorder) || !isHomeFlat(current) {
-            i-- // Retry
-            continue
+    tries := placementTries * scaledCount(w, 25)
+    for i := 0; i < scaledCount(w, 25); i++ {
+        cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+        if !ok {
+            break // No flat left to put it on
         }
 
-        treesInCluster := 12 + rand.Intn(9)
+        treesInCluster := 12 + w.rng.Intn(9)
         for j := 0; j < treesInCluster; j++ {
-            nx, ny := cx + rand.Intn(15)-7, cy + rand.Intn(15)-7
-            if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-                nidx := ny*GridSize + nx
+            nx, ny := cx + w.rng.Intn(15)-7, cy + w.rng.Intn(15)-7
+            if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+                nidx := ny*w.Width + nx
                 if w.Terrain[nidx] != uint8(TerrainBorder) {
                     if isHomeFlat(TerrainType(w.Terrain[nidx])) {
                         w.Terrain[nidx] = uint8(TerrainTrees)
@@ -541,20 +495,18 @@ func InitNorthSouth(w *World) {
     }
 
     // // === Rocks (15 clusters, 8-15 each, tighter) ===
-    for i := 0; i < 15; i++ {
-        cx, cy := rand.Intn(GridSize), rand.Intn(GridSize)
-        idx := cy*GridSize + cx
-        current := TerrainType(w.Terrain[idx])
-        if w.Terrain[idx] == uint8(TerrainBorder) || !isHomeFlat(current) {
-            i--
-            continue
+    tries = placementTries * scaledCount(w, 15)
+    for i := 0; i < scaledCount(w, 15); i++ {
+        cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+        if !ok {
+            break // No flat left to put it on
         }
 
-        rocksInCluster := 8 + rand.Intn(8)
+        rocksInCluster := 8 + w.rng.Intn(8)
         for j := 0; j < rocksInCluster; j++ {
-            nx, ny := cx + rand.Intn(11)-5, cy + rand.Intn(11)-5
-            if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-                nidx := ny*GridSize + nx
+            nx, ny := cx + w.rng.Intn(11)-5, cy + w.rng.Intn(11)-5
+            if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+                nidx := ny*w.Width + nx
                 if w.Terrain[nidx] != uint8(TerrainBorder) {
                     if isHomeFlat(TerrainType(w.Terrain[nidx])) {
                         w.Terrain[nidx] = uint8(TerrainRocks)
@@ -565,20 +517,18 @@ func InitNorthSouth(w *World) {
     }
 
     // // === Hills (20 areas, 20-40 each, larger) ===
-    for i := 0; i < 20; i++ {
-        cx, cy := rand.Intn(GridSize), rand.Intn(GridSize)
-        idx := cy*GridSize + cx
-        current := TerrainType(w.Terrain[idx])
-        if w.Terrain[idx] == uint8(TerrainBorder) || !isHomeFlat(current) {
-            i--
-            continue
+    tries = placementTries * scaledCount(w, 20)
+    for i := 0; i < scaledCount(w, 20); i++ {
+        cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+        if !ok {
+            break // No flat left to put it on
         }
 
-        hillsInArea := 20 + rand.Intn(21)
+        hillsInArea := 20 + w.rng.Intn(21)
         for j := 0; j < hillsInArea; j++ {
-            nx, ny := cx + rand.Intn(21)-10, cy + rand.Intn(21)-10
-            if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-                nidx := ny*GridSize + nx
+            nx, ny := cx + w.rng.Intn(21)-10, cy + w.rng.Intn(21)-10
+            if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+                nidx := ny*w.Width + nx
                 if w.Terrain[nidx] != uint8(TerrainBorder) {
                     if isHomeFlat(TerrainType(w.Terrain[nidx])) {
                         w.Terrain[nidx] = uint8(TerrainHills)
@@ -588,36 +538,143 @@ func InitNorthSouth(w *World) {
         }
     }
 
-    for tribe, cfg := range w.Tribes {
-        for i := 0; i < cfg.Starters; i++ {
-            placed := false
-            for attempts := 0; attempts < 1000; attempts++ {
-                x, y := rand.Intn(GridSize), rand.Intn(GridSize)
-                if w.Entities[y][x] == nil && TerrainType(w.Terrain[y*GridSize + x]) == cfg.HomeTerrain {
-                    counter := w.nextEntityID[tribe]
-                    if counter == nil {
-                        counter = new(uint32)
-                        w.nextEntityID[tribe] = counter
-                    }
-                    id := atomic.AddUint32(counter, 1)
-                    w.Entities[y][x] = &Entity{
-                        Health: 100,
-                        Tribe: tribe,
-                        Weapon: WeaponNone,
-                        Armor: ArmorNone,
-                        ID: id,
-                        Evasion: cfg.BaseEvasion,
-                        Rank: RankBase,
-                    }
-                    placed = true
-                    break
+    // === Berry bushes (20 patches, 5-9 each) ===
+    placeBerryPatches(w, isHomeFlat)
+
+    // === Ore (12 veins, 5-9 each) ===
+    placeOreVeins(w, isHomeFlat)
+
+    for _, tribe := range w.sortedTribeIDs() {
+        w.placeStarters(tribe, w.Tribes[tribe])
+    }
+}
+
+// Four tribes on their own islands in open water. Shallow fords link the two
+// top islands and the two bottom ones, top and bottom only meet by boat
+func InitIslands(w *World) {
+    for i := range w.Terrain {
+        w.Terrain[i] = uint8(TerrainWater)
+    }
+
+    w.Tribes = Races().mapTribes("islands")
+
+    // One island per quadrant, an ellipse with a wobbly coast
+    rx, ry := float64(w.Width / 5), float64(w.Height / 5)
+    if rx < 2 {
+        rx = 2
+    }
+    if ry < 2 {
+        ry = 2
+    }
+    islands := []struct {
+        cx, cy int
+        home TerrainType
+    }{
+        {w.Width / 4, w.Height / 4, TerrainRed},
+        {w.Width * 3 / 4, w.Height / 4, TerrainBlue},
+        {w.Width / 4, w.Height * 3 / 4, TerrainYellow},
+        {w.Width * 3 / 4, w.Height * 3 / 4, TerrainGreen},
+    }
+    const lobes = 12
+    for _, isl := range islands {
+        var coast [lobes]float64
+        for i := range coast {
+            coast[i] = 0.85 + 0.3*w.rng.Float64()
+        }
+
+        for y := 0; y < w.Height; y++ {
+            for x := 0; x < w.Width; x++ {
+                dx, dy := float64(x - isl.cx) / rx, float64(y - isl.cy) / ry
+                pos := (math.Atan2(dy, dx) + math.Pi) / (2 * math.Pi) * lobes
+                i := int(pos) % lobes
+                frac := pos - math.Floor(pos)
+                r := coast[i]*(1-frac) + coast[(i+1)%lobes]*frac
+                if dx*dx + dy*dy <= r*r {
+                    w.Terrain[y*w.Width + x] = uint8(isl.home)
+                }
+            }
+        }
+    }
+
+    // Fords, two rows wide, between the top pair and the bottom pair
+    for _, fy := range []int{w.Height / 4, w.Height * 3 / 4} {
+        for y := fy; y <= fy+1 && y < w.Height; y++ {
+            for x := w.Width / 4; x <= w.Width * 3 / 4; x++ {
+                if w.Terrain[y*w.Width + x] == uint8(TerrainWater) {
+                    w.Terrain[y*w.Width + x] = uint8(TerrainFord)
                 }
             }
-            if !placed {
-                log.Printf("Warning: Could not place starter for tribe %d (north-south map)", tribe)
+        }
+    }
+
+    homeFlats := make(map[TerrainType]bool)
+    for _, cfg := range w.Tribes {
+        homeFlats[cfg.HomeTerrain] = true
+    }
+    isHomeFlat := func(t TerrainType) bool {
+        return homeFlats[t]
+    }
+
+    // === Trees (15 forests, 12-20 trees each, about half the map is sea) ===
+    tries := placementTries * scaledCount(w, 15)
+    for i := 0; i < scaledCount(w, 15); i++ {
+        cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+        if !ok {
+            break // No flat left to put it on
+        }
+
+        treesInCluster := 12 + w.rng.Intn(9)
+        for j := 0; j < treesInCluster; j++ {
+            nx, ny := cx + w.rng.Intn(15)-7, cy + w.rng.Intn(15)-7
+            if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height && isHomeFlat(TerrainType(w.Terrain[ny*w.Width + nx])) {
+                w.Terrain[ny*w.Width + nx] = uint8(TerrainTrees)
+            }
+        }
+    }
+
+    // === Rocks (8 clusters, 8-15 each) ===
+    tries = placementTries * scaledCount(w, 8)
+    for i := 0; i < scaledCount(w, 8); i++ {
+        cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+        if !ok {
+            break // No flat left to put it on
+        }
+
+        rocksInCluster := 8 + w.rng.Intn(8)
+        for j := 0; j < rocksInCluster; j++ {
+            nx, ny := cx + w.rng.Intn(11)-5, cy + w.rng.Intn(11)-5
+            if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height && isHomeFlat(TerrainType(w.Terrain[ny*w.Width + nx])) {
+                w.Terrain[ny*w.Width + nx] = uint8(TerrainRocks)
             }
         }
     }
+
+    // === Hills (10 areas, 20-40 each) ===
+    tries = placementTries * scaledCount(w, 10)
+    for i := 0; i < scaledCount(w, 10); i++ {
+        cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+        if !ok {
+            break // No flat left to put it on
+        }
+
+        hillsInArea := 20 + w.rng.Intn(21)
+        for j := 0; j < hillsInArea; j++ {
+            nx, ny := cx + w.rng.Intn(21)-10, cy + w.rng.Intn(21)-10
+            if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height && isHomeFlat(TerrainType(w.Terrain[ny*w.Width + nx])) {
+                w.Terrain[ny*w.Width + nx] = uint8(TerrainHills)
+            }
+        }
+    }
+
+    // === Berry bushes (20 patches, 5-9 each) ===
+    placeBerryPatches(w, isHomeFlat)
+
+    // === Ore (12 veins, 5-9 each) ===
+    placeOreVeins(w, isHomeFlat)
+
+    for _, tribe := range w.sortedTribeIDs() {
+        w.placeStarters(tribe, w.Tribes[tribe])
+    }
 }
 
 func (w *World) InitCustomMap(terrain []uint8, assignments map[string]string) bool {
@@ -625,36 +682,38 @@ func (w *World) InitCustomMap(terrain []uint8, assignments map[string]string) bo
     defer w.Mu.Unlock()
 
     // Validate terrain size
-    if len(terrain) != GridSize * GridSize {
+    if len(terrain) != w.Width * w.Height {
         log.Println("Invalid custom map size")
         return false
     }
 
     copy(w.Terrain, terrain)
 
-    // Predefined tribe stat templates
-    type TribeTemplate struct {
-        DamageBonus  int
-        BaseEvasion  float64
-        DefenseBonus int
-        EntityVizCode uint8
-    }
-    
-    tribeTemplates := map[string]TribeTemplate{
-        "Wanderers": {DamageBonus: 0, BaseEvasion: 0.22, DefenseBonus: 0, EntityVizCode: 3},
-        "Norsca":    {DamageBonus: 0, BaseEvasion: 0.0, DefenseBonus: 2, EntityVizCode: 5},
-        "Nomads":    {DamageBonus: 2, BaseEvasion: 0.0, DefenseBonus: 0, EntityVizCode: 11},
-        "Sylvania":  {DamageBonus: 1, BaseEvasion: 0.12, DefenseBonus: 0, EntityVizCode: 12},
-    }
+    races := Races()
 
     w.Tribes = make(map[uint8]TribeConfig)
+    w.relations = make(map[[2]uint8]Relation)
+    w.leaderFell = make(map[uint8]int64)
+    w.dropFlowFields()
     tribeID := uint8(1)
 
+    // Assign tribe IDs in terrain-key order, not map order, so seeds replay
+    terrainKeys := make([]string, 0, len(assignments))
+    for terrainStr := range assignments {
+        terrainKeys = append(terrainKeys, terrainStr)
+    }
+    sort.Strings(terrainKeys)
+
     // Convert string terrain keys to uint8
-    for terrainStr, tribeName := range assignments {
+    for _, terrainStr := range terrainKeys {
+        tribeName := assignments[terrainStr]
         if tribeName == "none" {
             continue
         }
+        if tribeID > MaxTribes {
+            log.Printf("Custom map has more than %d tribes, ignoring %s", MaxTribes, tribeName)
+            break
+        }
         
         terrainType, err := strconv.Atoi(terrainStr)
         if err != nil {
@@ -662,21 +721,13 @@ func (w *World) InitCustomMap(terrain []uint8, assignments map[string]string) bo
             continue
         }
         
-        template, ok := tribeTemplates[tribeName]
+        race, ok := races.Race(tribeName)
         if !ok {
             log.Printf("Unknown tribe name: %s", tribeName)
             continue
         }
         
-        w.Tribes[tribeID] = TribeConfig{
-            HomeTerrain:   TerrainType(terrainType),
-            EntityVizCode: template.EntityVizCode,
-            Starters:      20,
-            Name:          tribeName,
-            DamageBonus:   template.DamageBonus,
-            BaseEvasion:   template.BaseEvasion,
-            DefenseBonus:  template.DefenseBonus,
-        }
+        w.Tribes[tribeID] = race.tribe(TerrainType(terrainType))
         
         tribeID++
     }
@@ -688,37 +739,13 @@ func (w *World) InitCustomMap(terrain []uint8, assignments map[string]string) bo
     }
     
     // Place starter entities for each tribe
-    for tribe, cfg := range w.Tribes {
-        for i := 0; i < cfg.Starters; i++ {
-            placed := false
-            for attempts := 0; attempts < 1000; attempts++ {
-                x, y := rand.Intn(GridSize), rand.Intn(GridSize)
-                if w.Entities[y][x] == nil && TerrainType(w.Terrain[y*GridSize+x]) == cfg.HomeTerrain {
-                    counter := w.nextEntityID[tribe]
-                    if counter == nil {
-                        counter = new(uint32)
-                        w.nextEntityID[tribe] = counter
-                    }
-                    id := atomic.AddUint32(counter, 1)
-                    w.Entities[y][x] = &Entity{
-                        Health:  100,
-                        Tribe:   tribe,
-                        Weapon:  WeaponNone,
-                        Armor:   ArmorNone,
-                        ID:      id,
-                        Evasion: cfg.BaseEvasion,
-                        Rank:    RankBase,
-                    }
-                    placed = true
-                    break
-                }
-            }
-            if !placed {
-                log.Printf("Warning: Could not place starter for tribe %d (%s)", tribe, cfg.Name)
-            }
-        }
+    for _, tribe := range w.sortedTribeIDs() {
+        w.placeStarters(tribe, w.Tribes[tribe])
     }
     
+    w.placeStartingVillages()
+    w.stockStartingFood()
+
     log.Printf("Custom map initialized with %d tribes", len(w.Tribes))
     return true
 }
\ No newline at end of file
@@ -0,0 +1,133 @@
+package world
+
+import (
+	"testing"
+	"time"
+)
+
+// Fails if a border cell still touches any tribe flat
+func assertNoBorderTouchesFlat(t *testing.T, w *World) {
+	t.Helper()
+
+	flats := make(map[TerrainType]bool)
+	for _, ft := range homeTerrains {
+		flats[ft] = true
+	}
+
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if TerrainType(w.Terrain[y*w.Width+x]) != TerrainBorder {
+				continue
+			}
+			for _, d := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+				nx, ny := x+d[0], y+d[1]
+				if nx < 0 || nx >= w.Width || ny < 0 || ny >= w.Height {
+					continue
+				}
+				if n := TerrainType(w.Terrain[ny*w.Width+nx]); flats[n] {
+					t.Fatalf("border at (%d,%d) still touches terrain %d at (%d,%d)", x, y, n, nx, ny)
+				}
+			}
+		}
+	}
+}
+
+func TestConvertBordersLayout(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rr|bb
+rr|bb
+||||^
+yy|gg
+yy|gg
+`, "")
+	w.ConvertBordersToTerrain()
+	assertNoBorderTouchesFlat(t, w)
+
+	if n := w.CountTerrain(uint8(TerrainBorder)); n != 0 {
+		t.Fatalf("%d border cells left, want 0", n)
+	}
+	if got := TerrainType(w.Terrain[2*w.Width+4]); got != TerrainRocks {
+		t.Fatalf("rocks at (4,2) became %d", got)
+	}
+}
+
+// The corner border only has border neighbours until they convert later in the scan
+func TestConvertBordersNeedsSecondPass(t *testing.T) {
+	w := newTestWorld(t, 1, `
+||r
+||r
+rrr
+`, "")
+	w.ConvertBordersToTerrain()
+	assertNoBorderTouchesFlat(t, w)
+
+	if n := w.CountTerrain(uint8(TerrainBorder)); n != 0 {
+		t.Fatalf("%d border cells left, want 0", n)
+	}
+}
+
+// A border walled off from every flat has nothing to become
+func TestConvertBordersLeavesIsolatedBorder(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rr^||
+rr^||
+`, "")
+	w.ConvertBordersToTerrain()
+
+	if n := w.CountTerrain(uint8(TerrainBorder)); n != 4 {
+		t.Fatalf("%d border cells left, want the 4 walled off", n)
+	}
+}
+
+func TestConvertBordersOnPresetMaps(t *testing.T) {
+	for _, mapName := range []string{"vertical", "northsouth", "fourquadrants"} {
+		for seed := int64(1); seed <= 3; seed++ {
+			w := newMapWorld(t, seed, mapName, 41, 31)
+			w.ConvertBordersToTerrain()
+			assertNoBorderTouchesFlat(t, w)
+		}
+	}
+}
+
+// No home flat left anywhere, placement has to give up instead of spinning
+func TestFeaturePlacementGivesUpWithoutFlat(t *testing.T) {
+	w := newTestWorld(t, 1, "^^^^^", "")
+	noFlat := func(TerrainType) bool { return false }
+
+	done := make(chan struct{})
+	go func() {
+		placeBerryPatches(w, noFlat)
+		placeOreVeins(w, noFlat)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("placement never gave up")
+	}
+	for x, c := range w.Terrain {
+		if TerrainType(c) != TerrainRocks {
+			t.Fatalf("cell %d changed to %v", x, TerrainType(c))
+		}
+	}
+}
+
+// The smallest grid leaves little home flat, so the classic maps must still finish
+func TestClassicMapsBuildAtMinimumSize(t *testing.T) {
+	for _, mapName := range []string{"vertical", "northsouth", "fourquadrants", "islands"} {
+		for seed := int64(1); seed <= 5; seed++ {
+			done := make(chan struct{})
+			go func() {
+				newMapWorld(t, seed, mapName, MinGridSize, MinGridSize)
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("%s map with seed %d never finished at %dx%d", mapName, seed, MinGridSize, MinGridSize)
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package world
+
+import "math"
+
+// Morale (0..MaxMorale) drains when a unit is wounded, outnumbered or sees
+// friends die, and recovers a little every tick, in peace too. Below
+// RetreatMorale the unit is routed: it stops pressing forward, falls back
+// toward its own home terrain and heals faster once it gets there
+const (
+	MaxMorale          = 100
+	RetreatMorale      = 30 // Routed below this
+	MoraleRecovery     = 2  // Per tick
+	HomeMoraleRecovery = 5  // Extra per tick on own home terrain, where routed units regroup
+	OutnumberedPenalty = 3  // Per enemy beyond the friends within MoraleRadius
+	WoundedHealth      = 30 // Below this health morale drains
+	WoundedPenalty     = 5  // Per tick while wounded
+	DeathShock         = 10 // Per friendly death within MoraleRadius this tick
+	MoraleRadius       = 2  // Chebyshev distance for friends, enemies and deaths
+	RetreatRegen       = 3  // Extra health per tick for a routed unit on home terrain
+)
+
+// A unit that died this tick, for DeathShock
+type death struct {
+	x, y   int
+	tribe  uint8
+	leader bool
+}
+
+func (e *Entity) Routed() bool {
+	return e.Morale < RetreatMorale
+}
+
+// Same tribe or allied, caller holds Mu
+func (w *World) friendly(a, b uint8) bool {
+	return a == b || w.relation(a, b) == RelationAlliance
+}
+
+// End-of-tick morale update for the survivors on ents, caller holds Mu
+func (w *World) updateMorale(ents [][]*Entity, deaths []death) {
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			ent := ents[y][x]
+			if ent == nil {
+				continue
+			}
+
+			friends, enemies := 0, 0
+			for ny := y - MoraleRadius; ny <= y+MoraleRadius; ny++ {
+				for nx := x - MoraleRadius; nx <= x+MoraleRadius; nx++ {
+					if nx < 0 || nx >= w.Width || ny < 0 || ny >= w.Height || (nx == x && ny == y) {
+						continue
+					}
+					other := ents[ny][nx]
+					if other == nil {
+						continue
+					}
+					if w.friendly(ent.Tribe, other.Tribe) {
+						friends++
+					} else if w.hostile(ent.Tribe, other.Tribe) {
+						enemies++
+					}
+				}
+			}
+
+			morale := ent.Morale + MoraleRecovery
+			if IsOwnTerrain(TerrainType(w.Terrain[y*w.Width+x]), w, ent.Tribe) {
+				morale += HomeMoraleRecovery
+			}
+			if enemies > friends {
+				morale -= (enemies - friends) * OutnumberedPenalty
+			}
+			if ent.Health < WoundedHealth {
+				morale -= WoundedPenalty
+			}
+			morale -= w.deathShock(ent, x, y, deaths)
+
+			if morale < 0 {
+				morale = 0
+			} else if morale > MaxMorale {
+				morale = MaxMorale
+			}
+			ent.Morale = morale
+		}
+	}
+}
+
+// Morale ent at (x, y) loses to friendly deaths within MoraleRadius, caller holds Mu
+func (w *World) deathShock(ent *Entity, x, y int, deaths []death) int {
+	shock := 0
+	for _, d := range deaths {
+		if d.x >= x-MoraleRadius && d.x <= x+MoraleRadius && d.y >= y-MoraleRadius && d.y <= y+MoraleRadius && w.friendly(ent.Tribe, d.tribe) {
+			shock += DeathShock
+		}
+	}
+
+	return shock
+}
+
+// Peace-time half of updateMorale: nobody's outnumbered or fighting, so
+// survivors recover as in war and take the DeathShock of friendly deaths
+// nearby. Without the recovery, shocks before the war would pile up and
+// armies would start it routed. caller holds Mu
+func (w *World) peaceMorale(ents [][]*Entity, deaths []death) {
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			ent := ents[y][x]
+			if ent == nil {
+				continue
+			}
+
+			morale := ent.Morale + MoraleRecovery
+			if IsOwnTerrain(TerrainType(w.Terrain[y*w.Width+x]), w, ent.Tribe) {
+				morale += HomeMoraleRecovery
+			}
+			morale -= w.deathShock(ent, x, y, deaths)
+
+			if morale < 0 {
+				morale = 0
+			} else if morale > MaxMorale {
+				morale = MaxMorale
+			}
+			ent.Morale = morale
+		}
+	}
+}
+
+// War move score for a routed unit stepping to (nx, ny): home terrain, away
+// from the enemy centre and clear of enemies. caller holds Mu
+func (w *World) retreatScore(ents [][]*Entity, nx, ny int, tribe uint8, currentDist, enemyCX, enemyCY float64, hasEnemies bool) float64 {
+	t := TerrainType(w.Terrain[ny*w.Width+nx])
+	score := 0.0
+	if IsOwnTerrain(t, w, tribe) {
+		score += 6.0
+	} else if IsEnemyTerrain(t, w, tribe) {
+		score -= 6.0
+	}
+
+	for ey := ny - 1; ey <= ny+1; ey++ {
+		for ex := nx - 1; ex <= nx+1; ex++ {
+			if ex >= 0 && ex < w.Width && ey >= 0 && ey < w.Height {
+				if other := ents[ey][ex]; other != nil && w.hostile(tribe, other.Tribe) {
+					score -= 4.0
+				}
+			}
+		}
+	}
+
+	if hasEnemies {
+		newDist := math.Abs(float64(nx)-enemyCX) + math.Abs(float64(ny)-enemyCY)
+		if gain := newDist - currentDist; gain > 0 {
+			score += gain * 3.0
+		}
+	}
+
+	return score
+}
@@ -0,0 +1,117 @@
+package world
+
+import "testing"
+
+func TestMoraleDropsWhenOutnumbered(t *testing.T) {
+	w := newTestWorld(t, 1, `
+.....
+.....
+.....
+`, `
+2...2
+..1..
+2....
+`)
+	ent := w.Entities[1][2]
+	ent.Morale = 50
+
+	w.updateMorale(w.Entities, nil)
+	// Recovery 2, three enemies against no friends
+	if want := 50 + MoraleRecovery - 3*OutnumberedPenalty; ent.Morale != want {
+		t.Fatalf("morale %d, want %d", ent.Morale, want)
+	}
+}
+
+func TestMoraleShockFromFriendlyDeath(t *testing.T) {
+	w := newTestWorld(t, 1, "....", ".1..")
+	ent := w.Entities[0][1]
+	ent.Morale = 50
+
+	w.updateMorale(w.Entities, []death{{x: 2, y: 0, tribe: 1}, {x: 3, y: 0, tribe: 2}})
+	if want := 50 + MoraleRecovery - DeathShock; ent.Morale != want {
+		t.Fatalf("morale %d, want %d (only the friend's death counts)", ent.Morale, want)
+	}
+}
+
+func TestRoutedUnitFallsBackHome(t *testing.T) {
+	w := newTestWorld(t, 1, "rr.....b", "..1....2")
+	w.EntityStats.MoveChance = 1
+	ent := w.Entities[0][2]
+	ent.Morale = 0
+	w.StartWar()
+
+	w.Update()
+	if w.Entities[0][1] != ent {
+		t.Fatal("routed unit didn't fall back onto home terrain")
+	}
+	if !ent.Routed() {
+		t.Fatalf("morale back to %d after one tick", ent.Morale)
+	}
+}
+
+func TestRoutedUnitRegensFasterAtHome(t *testing.T) {
+	w := newTestWorld(t, 1, "r#b", "1.2")
+	w.EntityStats.MoveChance = 0
+	routed := w.Entities[0][0]
+	routed.Morale, routed.Health = 0, 50
+	w.StartWar()
+
+	w.Update()
+	// Minimum hit of 1, then the usual regen of 3 plus RetreatRegen
+	if want := 50 - 1 + 3 + RetreatRegen; routed.Health != want {
+		t.Fatalf("routed unit at %d health, want %d", routed.Health, want)
+	}
+}
+
+func TestLongPeaceEndsWithNoRoutedUnits(t *testing.T) {
+	w := newMapWorld(t, 7, "vertical", 30, 30)
+	for i := 0; i < 1000; i++ {
+		w.PreWarUpdate()
+		if i == 500 {
+			// Plague-sized hit, the rest of the peace should heal it
+			for _, row := range w.Entities {
+				for _, ent := range row {
+					if ent != nil {
+						ent.Morale = 0
+					}
+				}
+			}
+		}
+	}
+
+	alive := 0
+	for _, row := range w.Entities {
+		for _, ent := range row {
+			if ent == nil {
+				continue
+			}
+			alive++
+			if ent.Routed() {
+				t.Fatalf("tribe %d unit still routed (morale %d) after the peace", ent.Tribe, ent.Morale)
+			}
+		}
+	}
+	if alive == 0 {
+		t.Fatal("nobody survived the peace")
+	}
+}
+
+func TestSnapshotKeepsMorale(t *testing.T) {
+	w := newMapWorld(t, 7, "vertical", 30, 30)
+	x, y := -1, -1
+	for yy := 0; yy < w.Height && x < 0; yy++ {
+		for xx := 0; xx < w.Width; xx++ {
+			if w.Entities[yy][xx] != nil {
+				x, y = xx, yy
+				break
+			}
+		}
+	}
+	w.Entities[y][x].Morale = 17
+
+	restored := roundTrip(t, w)
+
+	if got := restored.Entities[y][x].Morale; got != 17 {
+		t.Fatalf("morale %d after restore, want 17", got)
+	}
+}
@@ -0,0 +1,38 @@
+package world
+
+// Movement points. A unit earns a point on every tick it tries to move, up to
+// MaxMovePoints, and stepping onto a cell spends MoveCost of its terrain. Flats
+// take a step a try, hills and forests several, so terrain sets the pace
+const MaxMovePoints = 5 // Highest MoveCost, points past that would be wasted
+
+func MoveCost(t TerrainType) int {
+	switch t {
+	case TerrainHills, TerrainFord:
+		return 3
+
+	case TerrainTrees, TerrainRocks, TerrainOre:
+		return 5
+
+	case TerrainBerries:
+		return 2
+
+	default:
+		return 1
+	}
+}
+
+func (e *Entity) gainMovePoint() {
+	if e.MovePoints < MaxMovePoints {
+		e.MovePoints++
+	}
+}
+
+// True if ent has the points to step onto (x, y), caller holds Mu
+func (w *World) canEnter(ent *Entity, x, y int) bool {
+	return ent.MovePoints >= MoveCost(TerrainType(w.Terrain[y*w.Width+x]))
+}
+
+// Takes the points for a step onto (x, y), caller holds Mu
+func (w *World) payMove(ent *Entity, x, y int) {
+	ent.MovePoints -= MoveCost(TerrainType(w.Terrain[y*w.Width+x]))
+}
@@ -0,0 +1,53 @@
+package world
+
+import "testing"
+
+// A lone unit walking down a corridor toward an enemy, x after each war tick
+func corridorWalk(t *testing.T, terrain string, ticks int) []int {
+	t.Helper()
+
+	w := newTestWorld(t, 1, terrain, "1......2")
+	w.EntityStats.MoveChance = 1
+	ent := w.Entities[0][0]
+	w.StartWar()
+
+	xs := []int{}
+	for i := 0; i < ticks; i++ {
+		w.Update()
+		pos, ok := entityPositions(w)[ent]
+		if !ok {
+			t.Fatal("walker died")
+		}
+		xs = append(xs, pos[0])
+	}
+	return xs
+}
+
+func TestFlatsTakeOneTry(t *testing.T) {
+	xs := corridorWalk(t, "rrrrbbbb", 3)
+	if xs[0] != 1 || xs[1] != 2 || xs[2] != 3 {
+		t.Fatalf("positions %v, want a step every tick", xs)
+	}
+}
+
+func TestHillsTakeSeveralTries(t *testing.T) {
+	xs := corridorWalk(t, "rhrrbbbb", MoveCost(TerrainHills))
+	for i, x := range xs[:len(xs)-1] {
+		if x != 0 {
+			t.Fatalf("on the hills after %d tries, want %d", i+1, MoveCost(TerrainHills))
+		}
+	}
+	if xs[len(xs)-1] != 1 {
+		t.Fatalf("positions %v, want on the hills once the points are there", xs)
+	}
+}
+
+func TestMovePointsCap(t *testing.T) {
+	ent := &Entity{}
+	for i := 0; i < 2*MaxMovePoints; i++ {
+		ent.gainMovePoint()
+	}
+	if ent.MovePoints != MaxMovePoints {
+		t.Fatalf("saved %d points, want the cap of %d", ent.MovePoints, MaxMovePoints)
+	}
+}
@@ -0,0 +1,225 @@
+package world
+
+import "math"
+
+// Flow fields for long-range movement. A field holds, for every cell, the
+// cheapest cost of walking from there to the nearest goal over passable
+// terrain, paying pathCost for each cell entered. Units take the step that
+// goes down the field, so they route around rock ridges and forests instead
+// of grinding into them. Fields are rebuilt every PathTicks ticks and ignore
+// units, who move anyway. Snapshots keep them, see snapshotFlowFields
+const (
+	PathTicks = 10  // Ticks between rebuilds
+	PathPull  = 3.0 // Move score for a step down the field, same weight as the old centroid pull
+)
+
+const (
+	unreachable = int32(math.MaxInt32)
+	maxPathCost = MaxMovePoints // Highest pathCost
+)
+
+type flowField []int32
+
+// Cost of stepping onto t, the movement points it takes
+func pathCost(t TerrainType) int32 {
+	return int32(MoveCost(t))
+}
+
+// Cost of walking onto t, 0 = can't
+func landCost(t TerrainType) int32 {
+	if !IsPassable(t) {
+		return 0
+	}
+
+	return pathCost(t)
+}
+
+// Field over passable land, caller holds Mu
+func (w *World) buildFlowField(goals []int) flowField {
+	return w.buildField(goals, landCost)
+}
+
+// Field over open water for boats (see boats.go), caller holds Mu
+func (w *World) buildSeaField(goals []int) flowField {
+	return w.buildField(goals, seaCost)
+}
+
+// Dijkstra outward from the goal cells (indexes into Terrain), entering a
+// cell costs cost(terrain), 0 means it can't be entered. Step costs are
+// 1..maxPathCost, so a ring of buckets does instead of a heap. caller holds Mu
+func (w *World) buildField(goals []int, cost func(TerrainType) int32) flowField {
+	field := make(flowField, w.Width*w.Height)
+	for i := range field {
+		field[i] = unreachable
+	}
+
+	var ring [maxPathCost + 1][]int
+	pending := 0
+	for _, i := range goals {
+		if cost(TerrainType(w.Terrain[i])) > 0 && field[i] != 0 {
+			field[i] = 0
+			ring[0] = append(ring[0], i)
+			pending++
+		}
+	}
+
+	for d := int32(0); pending > 0; d++ {
+		slot := d % int32(len(ring))
+		for _, i := range ring[slot] { // Steps cost at least 1, nothing lands back in this slot
+			pending--
+			if field[i] != d {
+				continue // Already reached cheaper
+			}
+
+			// Walking from a neighbour into i costs i's terrain
+			step := d + cost(TerrainType(w.Terrain[i]))
+			x, y := i%w.Width, i/w.Width
+			for _, dir := range [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} {
+				nx, ny := x+dir[0], y+dir[1]
+				if nx < 0 || nx >= w.Width || ny < 0 || ny >= w.Height {
+					continue
+				}
+				j := ny*w.Width + nx
+				if step < field[j] && cost(TerrainType(w.Terrain[j])) > 0 {
+					field[j] = step
+					next := step % int32(len(ring))
+					ring[next] = append(ring[next], j)
+					pending++
+				}
+			}
+		}
+		ring[slot] = ring[slot][:0]
+	}
+
+	return field
+}
+
+// Drops the fields every PathTicks so they get rebuilt from the current map.
+// Attack fields (war only) lead each tribe to the nearest unit it's at war
+// with and are built up front, every tribe needs one. caller holds Mu
+func (w *World) refreshFlowFields(war bool) {
+	stale := w.homeFields == nil || (war && w.attackFields == nil)
+	if !stale && w.tickCount%PathTicks != 0 {
+		return
+	}
+
+	w.homeFields = make(map[uint8]flowField)
+	w.landingFields = make(map[uint8]flowField)
+	w.dockFields = make(map[uint8]flowField)
+	w.embarkFields = make(map[uint8]flowField)
+	w.attackFields = nil
+	if !war {
+		return
+	}
+
+	w.attackFields = make(map[uint8]flowField)
+	units := w.unitCells()
+	for _, tribe := range w.sortedTribeIDs() {
+		w.attackFields[tribe] = w.buildAttackField(tribe, units)
+	}
+}
+
+// Cells (indexes into Terrain) of every tribe's units, caller holds Mu
+func (w *World) unitCells() map[uint8][]int {
+	units := make(map[uint8][]int)
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if ent := w.Entities[y][x]; ent != nil {
+				units[ent.Tribe] = append(units[ent.Tribe], y*w.Width+x)
+			}
+		}
+	}
+
+	return units
+}
+
+// Field toward every unit of a tribe at war with tribe, caller holds Mu
+func (w *World) buildAttackField(tribe uint8, units map[uint8][]int) flowField {
+	var enemies []int
+	for _, other := range w.sortedTribeIDs() {
+		if w.hostile(tribe, other) {
+			enemies = append(enemies, units[other]...)
+		}
+	}
+
+	return w.buildFlowField(enemies)
+}
+
+// The tribe's attack field, nil before the war. God powers drop the set
+// mid-war, a field asked for before the next refresh gets built
+// here instead. caller holds Mu
+func (w *World) attackField(tribe uint8) flowField {
+	if !w.warStarted {
+		return nil
+	}
+	if f, ok := w.attackFields[tribe]; ok {
+		return f
+	}
+
+	if w.attackFields == nil {
+		w.attackFields = make(map[uint8]flowField)
+	}
+	f := w.buildAttackField(tribe, w.unitCells())
+	w.attackFields[tribe] = f
+
+	return f
+}
+
+// Throws every field away so the next tick rebuilds them, for anything that
+// changes the map, the lineup or the grid size under them. caller holds Mu
+func (w *World) dropFlowFields() {
+	w.homeFields, w.attackFields = nil, nil
+	w.landingFields, w.dockFields, w.embarkFields = nil, nil, nil
+}
+
+// Field back to the tribe's own flats, built the first time it's asked for
+// after a refresh since only strays and routed units need it. caller holds Mu
+func (w *World) homeField(tribe uint8) flowField {
+	if f, ok := w.homeFields[tribe]; ok || w.homeFields == nil {
+		return f
+	}
+
+	home := w.Tribes[tribe].HomeTerrain
+	var flats []int
+	for i, t := range w.Terrain {
+		if TerrainType(t) == home {
+			flats = append(flats, i)
+		}
+	}
+	f := w.buildFlowField(flats)
+	w.homeFields[tribe] = f
+
+	return f
+}
+
+// Bitmask over directions of the free neighbours of (x, y) that lead down f
+// most cheaply. ok is false when f doesn't reach (x, y), callers fall back to
+// their old scoring then. caller holds Mu
+func (w *World) downhill(f flowField, x, y int, directions [][2]int) (steps uint8, ok bool) {
+	if f == nil || f[y*w.Width+x] == unreachable {
+		return 0, false
+	}
+
+	here := f[y*w.Width+x]
+	best := unreachable
+	for d, dir := range directions {
+		nx, ny := x+dir[0], y+dir[1]
+		if nx < 0 || nx >= w.Width || ny < 0 || ny >= w.Height || w.Entities[ny][nx] != nil {
+			continue
+		}
+		j := ny*w.Width + nx
+		if f[j] >= here {
+			continue // Only steps that get closer
+		}
+
+		cost := f[j] + pathCost(TerrainType(w.Terrain[j]))
+		if cost < best {
+			best = cost
+			steps = 1 << d
+		} else if cost == best {
+			steps |= 1 << d
+		}
+	}
+
+	return steps, true
+}
@@ -0,0 +1,113 @@
+package world
+
+import (
+	"bytes"
+	"testing"
+)
+
+const (
+	stepUp    = 1 << 0
+	stepDown  = 1 << 1
+	stepLeft  = 1 << 2
+	stepRight = 1 << 3
+)
+
+var testDirections = [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}}
+
+func TestAttackFieldRoutesAroundWall(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rrrrr
+.####
+bbbbb
+`, `
+...1.
+.....
+...2.
+`)
+	w.StartWar()
+	w.refreshFlowFields(true)
+
+	steps, ok := w.downhill(w.attackFields[1], 3, 0, testDirections)
+	if !ok || steps != stepLeft {
+		t.Fatalf("steps %04b ok %v, want left toward the gap", steps, ok)
+	}
+}
+
+func TestFlowFieldDetoursAroundRocks(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rrrrr
+.^^^^
+.^^^^
+.^^^^
+bbbbb
+`, "")
+	f := w.buildFlowField([]int{4*w.Width + 3})
+
+	// 3 left, 4 down, 3 right beats 3 rocks at 5 each plus the goal
+	if got := f[3]; got != 10 {
+		t.Fatalf("cost from (3,0) is %d, want 10 around the rocks", got)
+	}
+	if steps, _ := w.downhill(f, 3, 0, testDirections); steps != stepLeft {
+		t.Fatalf("steps %04b, want left", steps)
+	}
+}
+
+func TestWarMoveFollowsAttackField(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rrrrr
+.####
+bbbbb
+`, `
+...1.
+.....
+...2.
+`)
+	w.EntityStats.MoveChance = 1
+	ent := w.Entities[0][3]
+	w.StartWar()
+
+	w.Update()
+	if w.Entities[0][2] != ent {
+		t.Fatal("unit didn't step toward the gap in the wall")
+	}
+}
+
+// Boxed into empty land, greedy scoring finds nothing worth a step. The home
+// field walks the stray back
+func TestStrayFollowsHomeField(t *testing.T) {
+	w := newTestWorld(t, 1, `
+r#...
+r#.#.
+rrr#.
+`, `
+....1
+.....
+.....
+`)
+	w.EntityStats.MoveChance = 1
+	w.EntityStats.ReproductionRate = 0
+	ent := w.Entities[0][4]
+
+	w.PreWarUpdate()
+	if w.Entities[0][3] != ent {
+		t.Fatal("stray didn't start back toward home")
+	}
+}
+
+func TestRestoredWarMatchesOriginalRun(t *testing.T) {
+	w := newMapWorld(t, 7, "fourquadrants", 40, 40)
+	w.StartWar()
+	for i := 0; i < 23; i++ { // Mid-way between two field rebuilds
+		w.Update()
+	}
+
+	restored := roundTrip(t, w)
+
+	for i := 0; i < 3*PathTicks; i++ {
+		w.Update()
+		restored.Update()
+		if !bytes.Equal(w.GetGridCopy(), restored.GetGridCopy()) {
+			t.Fatalf("restored run diverged %d ticks after the snapshot", i+1)
+		}
+	}
+}
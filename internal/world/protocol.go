@@ -0,0 +1,102 @@
+package world
+
+import (
+	"encoding/binary"
+)
+
+// Binary grid frames sent to clients all start with the same header:
+//
+//	[0]   frame type (FrameFullGrid or FrameGridDelta), OR'd with FrameFlagEdit
+//	[1:5] world tick that produced the frame, uint32 big-endian
+//	[5:7] width, uint16 big-endian
+//	[7:9] height, uint16 big-endian
+//
+// FrameFullGrid (keyframe) follows with width*height cell codes, row-major.
+// FrameGridDelta follows with only the cells that changed since the
+// previous frame that client received, as spans:
+//
+//	[9:13] span count, uint32 big-endian
+//	per span: start cell index uint32, length uint16, then length cell codes
+//
+// A tick that changed nothing still gets a delta with zero spans, so clients
+// can tell a quiet tick from a dropped frame.
+//
+// Tick frames go out at most once per tick. Frames with FrameFlagEdit carry
+// player edits (placing, reset, load) made between ticks and reuse the
+// current tick number.
+const (
+	FrameFullGrid  uint8 = 1
+	FrameGridDelta uint8 = 2
+	FrameFlagEdit  uint8 = 0x80
+
+	gridHeaderLen      = 9
+	deltaSpanHeaderLen = 6
+	maxDeltaSpanLen    = 0xFFFF
+)
+
+// Full grid frame with the dimensions in the header, size and cells read under one lock
+func (w *World) GridFrame() []byte {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+
+	frame := make([]byte, gridHeaderLen+w.Width*w.Height)
+	putGridHeader(frame, FrameFullGrid, w.tickCount, w.Width, w.Height)
+	w.fillGrid(frame[gridHeaderLen:])
+
+	return frame
+}
+
+func putGridHeader(frame []byte, frameType uint8, tick int64, width, height int) {
+	frame[0] = frameType
+	binary.BigEndian.PutUint32(frame[1:5], uint32(tick))
+	binary.BigEndian.PutUint16(frame[5:7], uint16(width))
+	binary.BigEndian.PutUint16(frame[7:9], uint16(height))
+}
+
+func gridFrameTick(frame []byte) int64 {
+	return int64(binary.BigEndian.Uint32(frame[1:5]))
+}
+
+// Width and height from a frame header
+func gridFrameSize(frame []byte) (width, height int) {
+	return int(binary.BigEndian.Uint16(frame[5:7])), int(binary.BigEndian.Uint16(frame[7:9]))
+}
+
+// Delta frame turning prev into cur (same size), with zero spans when nothing
+// changed. Changed cells closer together than a span header are merged into one span
+func encodeGridDelta(frameType uint8, tick int64, width, height int, prev, cur []uint8) []byte {
+	type span struct{ start, end int } // end exclusive
+	spans := []span{}
+
+	for i := 0; i < len(cur); i++ {
+		if cur[i] == prev[i] {
+			continue
+		}
+
+		last := len(spans) - 1
+		if last >= 0 && i-spans[last].end <= deltaSpanHeaderLen && i+1-spans[last].start <= maxDeltaSpanLen {
+			spans[last].end = i + 1
+		} else {
+			spans = append(spans, span{start: i, end: i + 1})
+		}
+	}
+
+	size := gridHeaderLen + 4
+	for _, s := range spans {
+		size += deltaSpanHeaderLen + s.end - s.start
+	}
+
+	frame := make([]byte, size)
+	putGridHeader(frame, frameType, tick, width, height)
+	binary.BigEndian.PutUint32(frame[gridHeaderLen:gridHeaderLen+4], uint32(len(spans)))
+
+	pos := gridHeaderLen + 4
+	for _, s := range spans {
+		binary.BigEndian.PutUint32(frame[pos:pos+4], uint32(s.start))
+		binary.BigEndian.PutUint16(frame[pos+4:pos+6], uint16(s.end-s.start))
+		pos += deltaSpanHeaderLen
+		pos += copy(frame[pos:], cur[s.start:s.end])
+	}
+
+	return frame
+}
@@ -0,0 +1,105 @@
+package world
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// A quiet tick still needs a frame, or clients take it as dropped
+func TestUnchangedGridDeltaCarriesTick(t *testing.T) {
+	cells := []uint8{1, 2, 3, 4}
+	frame := encodeGridDelta(FrameGridDelta, 42, 2, 2, cells, cells)
+
+	if len(frame) != gridHeaderLen+4 {
+		t.Fatalf("frame is %d bytes, want a bare header and span count", len(frame))
+	}
+	if tick := gridFrameTick(frame); tick != 42 {
+		t.Fatalf("tick %d, want 42", tick)
+	}
+	if spans := binary.BigEndian.Uint32(frame[gridHeaderLen:]); spans != 0 {
+		t.Fatalf("%d spans, want 0", spans)
+	}
+}
+
+// Applies a delta frame to prev the way clients do, returns the span count
+func applyGridDelta(t *testing.T, frame []byte, prev []uint8) ([]uint8, int) {
+	t.Helper()
+
+	cells := append([]uint8(nil), prev...)
+	spans := int(binary.BigEndian.Uint32(frame[gridHeaderLen:]))
+	pos := gridHeaderLen + 4
+	for i := 0; i < spans; i++ {
+		start := int(binary.BigEndian.Uint32(frame[pos:]))
+		length := int(binary.BigEndian.Uint16(frame[pos+4:]))
+		pos += deltaSpanHeaderLen
+		copy(cells[start:start+length], frame[pos:pos+length])
+		pos += length
+	}
+	if pos != len(frame) {
+		t.Fatalf("decoded %d of %d bytes", pos, len(frame))
+	}
+
+	return cells, spans
+}
+
+func TestGridDeltaRoundTrip(t *testing.T) {
+	const size = 100
+	tests := []struct {
+		name    string
+		changed []int // Cells that differ between prev and cur
+		spans   int
+	}{
+		{"single cell", []int{10}, 1},
+		{"adjacent cells", []int{10, 11, 12}, 1},
+		{"gap a header wide merges", []int{10, 10 + deltaSpanHeaderLen + 1}, 1},
+		{"wider gap splits", []int{10, 10 + deltaSpanHeaderLen + 2}, 2},
+		{"first cell", []int{0}, 1},
+		{"final cell", []int{size - 1}, 1},
+		{"near the final cell", []int{size - 4, size - 1}, 1},
+		{"both ends", []int{0, size - 1}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prev := make([]uint8, size)
+			cur := make([]uint8, size)
+			for _, i := range tt.changed {
+				cur[i] = 7
+			}
+
+			frame := encodeGridDelta(FrameGridDelta, 5, 10, 10, prev, cur)
+			if w, h := gridFrameSize(frame); w != 10 || h != 10 || gridFrameTick(frame) != 5 {
+				t.Fatalf("header %dx%d tick %d", w, h, gridFrameTick(frame))
+			}
+			got, spans := applyGridDelta(t, frame, prev)
+			if spans != tt.spans {
+				t.Fatalf("%d spans, want %d", spans, tt.spans)
+			}
+			for i := range cur {
+				if got[i] != cur[i] {
+					t.Fatalf("cell %d decoded as %d, want %d", i, got[i], cur[i])
+				}
+			}
+		})
+	}
+}
+
+// A change longer than a span can hold is split across spans
+func TestGridDeltaSplitsLongSpans(t *testing.T) {
+	prev := make([]uint8, 400*200)
+	cur := make([]uint8, len(prev))
+	for i := range cur {
+		cur[i] = 3
+	}
+
+	frame := encodeGridDelta(FrameGridDelta, 1, 400, 200, prev, cur)
+	got, spans := applyGridDelta(t, frame, prev)
+	if spans != 2 {
+		t.Fatalf("%d spans, want 2", spans)
+	}
+	for i := range cur {
+		if got[i] != cur[i] {
+			t.Fatalf("cell %d decoded as %d", i, got[i])
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package world
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Built-in races and preset map lineups, used unless a file is loaded with LoadRaceConfig
+//
+//go:embed races.json
+var defaultRacesJSON []byte
+
+// One playable race, as designers write it in the races file
+type Race struct {
+	Name          string  `json:"name"`
+	Description   string  `json:"description"` // Shown next to the name in the custom map picker
+	DamageBonus   int     `json:"damageBonus"`
+	DefenseBonus  int     `json:"defenseBonus"`
+	BaseEvasion   float64 `json:"baseEvasion"` // 0-1
+	EntityVizCode uint8   `json:"entityVizCode"`
+	Starters      int     `json:"starters"`
+	Sprites       string  `json:"sprites"`  // Client sprite set (grass, snow, desert, cemetery)
+	Lifespan      int64   `json:"lifespan"` // Ticks, 0 = DefaultLifespan
+}
+
+// One tribe of a preset map. Tribe IDs follow slot order starting at 1
+type MapSlot struct {
+	Race          string      `json:"race"`
+	HomeTerrain   TerrainType `json:"homeTerrain"`
+	EntityVizCode uint8       `json:"entityVizCode,omitempty"` // Overrides the race's, preset clients draw by slot code
+}
+
+type RaceConfig struct {
+	Races []Race               `json:"races"`
+	Maps  map[string][]MapSlot `json:"maps"`
+}
+
+const maxStarters = 1000
+
+// Tribes a map can hold, one per home flat
+const MaxTribes = 4
+
+// Grid codes already taken by terrain and buildings, entities can't render as these
+var terrainGridCodes = append([]TerrainType{
+	TerrainEmpty, TerrainRed, TerrainBlue, TerrainBorder, TerrainTrees,
+	TerrainRocks, TerrainHills, TerrainYellow, TerrainGreen, TerrainBerries, TerrainOre,
+	TerrainWater, TerrainFord, TerrainFire, TerrainBurnt,
+	TerrainType(VillageVizCode),
+}, tribeGridCodes()...)
+
+// Leader and boat codes of every tribe a map can hold
+func tribeGridCodes() []TerrainType {
+	var codes []TerrainType
+	for tribe := uint8(1); tribe <= MaxTribes; tribe++ {
+		codes = append(codes, TerrainType(LeaderVizCode(tribe)), TerrainType(BoatVizCode(tribe)))
+	}
+
+	return codes
+}
+
+// Flats a tribe can call home, MaxTribes of them
+var homeTerrains = []TerrainType{TerrainRed, TerrainBlue, TerrainYellow, TerrainGreen}
+
+// Flats each preset map paints, its lineup has to cover exactly these
+var presetMapTerrains = map[string][]TerrainType{
+	"vertical":      {TerrainRed, TerrainBlue},
+	"northsouth":    {TerrainRed, TerrainBlue},
+	"fourquadrants": {TerrainRed, TerrainBlue, TerrainYellow, TerrainGreen},
+	"islands":       {TerrainRed, TerrainBlue, TerrainYellow, TerrainGreen},
+}
+
+var (
+	raceMu     sync.RWMutex
+	raceConfig = mustParseDefaultRaces()
+)
+
+func mustParseDefaultRaces() *RaceConfig {
+	cfg, err := ParseRaceConfig(defaultRacesJSON)
+	if err != nil {
+		panic("built-in races.json: " + err.Error())
+	}
+
+	return cfg
+}
+
+// Decodes and validates a races file
+func ParseRaceConfig(data []byte) (*RaceConfig, error) {
+	var cfg RaceConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("decode races: %w", err)
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// Reads a races file and makes it the active config for maps built from now on
+func LoadRaceConfig(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := ParseRaceConfig(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	raceMu.Lock()
+	raceConfig = cfg
+	raceMu.Unlock()
+	return nil
+}
+
+// Active race config, treat as read-only
+func Races() *RaceConfig {
+	raceMu.RLock()
+	defer raceMu.RUnlock()
+	return raceConfig
+}
+
+func (c *RaceConfig) validate() error {
+	if len(c.Races) == 0 {
+		return fmt.Errorf("no races defined")
+	}
+
+	reserved := make(map[uint8]bool)
+	for _, t := range terrainGridCodes {
+		reserved[uint8(t)] = true
+	}
+
+	names := make(map[string]bool)
+	codes := make(map[uint8]string)
+	for i, r := range c.Races {
+		if r.Name == "" {
+			return fmt.Errorf("race %d has no name", i)
+		}
+		if r.Name == "none" {
+			return fmt.Errorf("race name %q is reserved", r.Name)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("race %q defined twice", r.Name)
+		}
+		names[r.Name] = true
+
+		if r.DamageBonus < 0 || r.DefenseBonus < 0 {
+			return fmt.Errorf("race %q: bonuses can't be negative", r.Name)
+		}
+		if r.BaseEvasion < 0 || r.BaseEvasion >= 1 {
+			return fmt.Errorf("race %q: baseEvasion %.2f outside [0, 1)", r.Name, r.BaseEvasion)
+		}
+		if r.Lifespan < 0 {
+			return fmt.Errorf("race %q: lifespan %d can't be negative", r.Name, r.Lifespan)
+		}
+		if r.Starters < 1 || r.Starters > maxStarters {
+			return fmt.Errorf("race %q: starters %d outside 1..%d", r.Name, r.Starters, maxStarters)
+		}
+		if r.Sprites == "" {
+			return fmt.Errorf("race %q has no sprite set", r.Name)
+		}
+		if reserved[r.EntityVizCode] {
+			return fmt.Errorf("race %q: entityVizCode %d is taken by terrain or buildings", r.Name, r.EntityVizCode)
+		}
+		if other, ok := codes[r.EntityVizCode]; ok {
+			return fmt.Errorf("races %q and %q share entityVizCode %d", other, r.Name, r.EntityVizCode)
+		}
+		codes[r.EntityVizCode] = r.Name
+	}
+
+	flats := make(map[TerrainType]bool)
+	for _, t := range homeTerrains {
+		flats[t] = true
+	}
+
+	for _, name := range c.mapNames() {
+		slots := c.Maps[name]
+		if len(slots) == 0 {
+			return fmt.Errorf("map %q has no tribes", name)
+		}
+
+		terrains := make(map[TerrainType]bool)
+		slotCodes := make(map[uint8]bool)
+		for i, slot := range slots {
+			if !names[slot.Race] {
+				return fmt.Errorf("map %q slot %d: unknown race %q", name, i+1, slot.Race)
+			}
+			if !flats[slot.HomeTerrain] {
+				return fmt.Errorf("map %q slot %d: homeTerrain %d is not a tribe flat", name, i+1, slot.HomeTerrain)
+			}
+			if terrains[slot.HomeTerrain] {
+				return fmt.Errorf("map %q: two tribes on homeTerrain %d", name, slot.HomeTerrain)
+			}
+			terrains[slot.HomeTerrain] = true
+
+			code := slot.EntityVizCode
+			if code == 0 {
+				race, _ := c.Race(slot.Race)
+				code = race.EntityVizCode
+			}
+			if reserved[code] {
+				return fmt.Errorf("map %q slot %d: entityVizCode %d is taken by terrain or buildings", name, i+1, code)
+			}
+			if slotCodes[code] {
+				return fmt.Errorf("map %q: two tribes share entityVizCode %d", name, code)
+			}
+			slotCodes[code] = true
+		}
+	}
+
+	for name, want := range presetMapTerrains {
+		slots, ok := c.Maps[name]
+		if !ok {
+			return fmt.Errorf("preset map %q has no lineup", name)
+		}
+		if len(slots) != len(want) {
+			return fmt.Errorf("map %q needs %d tribes, got %d", name, len(want), len(slots))
+		}
+		for _, t := range want {
+			found := false
+			for _, slot := range slots {
+				found = found || slot.HomeTerrain == t
+			}
+			if !found {
+				return fmt.Errorf("map %q has no tribe on homeTerrain %d", name, t)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *RaceConfig) mapNames() []string {
+	names := make([]string, 0, len(c.Maps))
+	for name := range c.Maps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *RaceConfig) Race(name string) (Race, bool) {
+	for _, r := range c.Races {
+		if r.Name == name {
+			return r, true
+		}
+	}
+
+	return Race{}, false
+}
+
+// Tribe config for a race living on home
+func (r Race) tribe(home TerrainType) TribeConfig {
+	cfg := TribeConfig{
+		HomeTerrain:   home,
+		EntityVizCode: r.EntityVizCode,
+		Starters:      r.Starters,
+		Name:          r.Name,
+		DamageBonus:   r.DamageBonus,
+		BaseEvasion:   r.BaseEvasion,
+		DefenseBonus:  r.DefenseBonus,
+		Sprites:       r.Sprites,
+		Lifespan:      r.Lifespan,
+	}
+	if cfg.Lifespan == 0 {
+		cfg.Lifespan = DefaultLifespan
+	}
+
+	return cfg
+}
+
+// Tribes for a preset map's lineup
+func (c *RaceConfig) mapTribes(mapName string) map[uint8]TribeConfig {
+	slots := c.Maps[mapName]
+	tribes := make(map[uint8]TribeConfig)
+	for i, slot := range slots {
+		race, _ := c.Race(slot.Race) // Checked by validate
+		cfg := race.tribe(slot.HomeTerrain)
+		if slot.EntityVizCode != 0 {
+			cfg.EntityVizCode = slot.EntityVizCode
+		}
+		tribes[uint8(i+1)] = cfg
+	}
+
+	return tribes
+}
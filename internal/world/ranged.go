@@ -0,0 +1,70 @@
+package world
+
+// Rocks and trees stop arrows, entities and everything else don't
+func blocksSight(t TerrainType) bool {
+	return t == TerrainRocks || t == TerrainTrees || t == TerrainOre
+}
+
+// True if nothing on the line between the two cells (ends excluded) blocks sight
+func hasLineOfSight(w *World, x0, y0, x1, y1 int) bool {
+	dx, dy := x1-x0, y1-y0
+	sx, sy := 1, 1
+	if dx < 0 {
+		dx, sx = -dx, -1
+	}
+	if dy < 0 {
+		dy, sy = -dy, -1
+	}
+
+	// Bresenham walk from the shooter to the target
+	err := dx - dy
+	x, y := x0, y0
+	for {
+		e2 := 2 * err
+		if e2 > -dy {
+			err -= dy
+			x += sx
+		}
+		if e2 < dx {
+			err += dx
+			y += sy
+		}
+		if x == x1 && y == y1 {
+			return true
+		}
+		if blocksSight(TerrainType(w.Terrain[y*w.Width+x])) {
+			return false
+		}
+	}
+}
+
+// Nearest visible enemy (a tribe at war with us) within reach of the archer at (x, y) on ents.
+// Ties go to the first in row order so replays stay stable
+func archerTarget(w *World, ents [][]*Entity, x, y, reach int) (tx, ty int, ok bool) {
+	tribe := ents[y][x].Tribe
+	best := reach*reach + 1
+
+	for cy := y - reach; cy <= y+reach; cy++ {
+		if cy < 0 || cy >= w.Height {
+			continue
+		}
+		for cx := x - reach; cx <= x+reach; cx++ {
+			if cx < 0 || cx >= w.Width {
+				continue
+			}
+
+			target := ents[cy][cx]
+			if target == nil || !w.hostile(tribe, target.Tribe) {
+				continue
+			}
+
+			dist := (cx-x)*(cx-x) + (cy-y)*(cy-y)
+			if dist >= best || !hasLineOfSight(w, x, y, cx, cy) {
+				continue
+			}
+			tx, ty, best, ok = cx, cy, dist, true
+		}
+	}
+
+	return tx, ty, ok
+}
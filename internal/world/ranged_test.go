@@ -0,0 +1,54 @@
+package world
+
+import "testing"
+
+// One war tick with an archer at (0,0), returns the health of the entity at (tx,0)
+func shootOnce(t *testing.T, terrain, entities string, tx int) int {
+	t.Helper()
+
+	w := newTestWorld(t, 1, terrain, entities)
+	w.EntityStats.MoveChance = 0
+	w.Entities[0][0].Weapon = WeaponBow
+	w.StartWar()
+
+	target := w.Entities[0][tx]
+	w.Update()
+	return target.Health
+}
+
+func TestArcherHitsAtRange(t *testing.T) {
+	// Unhurt entities lose the minimum 1 and regen 3, so anything under 100 is an arrow
+	if hp := shootOnce(t, "rrrrbb", "1...2.", 4); hp >= 100 {
+		t.Fatalf("target 4 cells away not hit, health %d", hp)
+	}
+}
+
+func TestArcherOutOfRange(t *testing.T) {
+	if hp := shootOnce(t, "rrrrrb", "1....2", 5); hp != 100 {
+		t.Fatalf("target 5 cells away was hit, health %d", hp)
+	}
+}
+
+func TestArcherBlockedBySight(t *testing.T) {
+	for _, blocker := range []string{"^", "T"} {
+		if hp := shootOnce(t, "rr"+blocker+"rb", "1...2", 4); hp != 100 {
+			t.Fatalf("shot through %q, health %d", blocker, hp)
+		}
+	}
+}
+
+func TestArcherPicksNearest(t *testing.T) {
+	w := newTestWorld(t, 1, "rbbbb", "12..2")
+	w.EntityStats.MoveChance = 0
+	w.Entities[0][0].Weapon = WeaponBow
+	w.StartWar()
+
+	near, far := w.Entities[0][1], w.Entities[0][4]
+	w.Update()
+	if far.Health != 100 {
+		t.Fatalf("far target hit while an adjacent one was in sight, health %d", far.Health)
+	}
+	if near.Health >= 100 {
+		t.Fatalf("adjacent target not hit, health %d", near.Health)
+	}
+}
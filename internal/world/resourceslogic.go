@@ -1,15 +1,21 @@
 package world
 
-import (
-	"time"
-)
-
 type WeaponType uint8
 
 const (
 	WeaponNone WeaponType = 0
 	WeaponWood WeaponType = 1
 	WeaponStone WeaponType = 2
+	WeaponBow WeaponType = 3 // Makes the entity an archer
+	WeaponIron WeaponType = 4 // Needs TechIronWorking
+	WeaponSteel WeaponType = 5 // Needs TechSteelMaking
+)
+
+// Bows hit softer than swords but reach BowRange cells. They cost a bit more wood
+// than a wood sword, which already keeps archers a minority since wood rarely piles up
+const (
+	BowRange = 4
+	BowWoodCost = 4
 )
 
 // depending on weapon type, adds bonus damage to entity
@@ -21,17 +27,37 @@ func (wt WeaponType) Bonus() int {
 	case WeaponStone:
 		return 4
 
+	case WeaponBow:
+		return 2
+
+	case WeaponIron:
+		return 6
+
+	case WeaponSteel:
+		return 8
+
 	default:
 		return 0
 	}
 }
 
+// How far (in cells) the weapon reaches, 1 is melee
+func (wt WeaponType) Range() int {
+	if wt == WeaponBow {
+		return BowRange
+	}
+
+	return 1
+}
+
 type ArmorType uint8
 
 const (
 	ArmorNone ArmorType = 0
 	ArmorWood ArmorType = 1
 	ArmorStone ArmorType = 2
+	ArmorIron ArmorType = 3 // Needs TechIronWorking
+	ArmorSteel ArmorType = 4 // Needs TechSteelMaking
 )
 
 func (e ArmorType) Defensebonus() int {
@@ -42,11 +68,45 @@ func (e ArmorType) Defensebonus() int {
 	case ArmorStone:
 		return 3
 
+	case ArmorIron:
+		return 4
+
+	case ArmorSteel:
+		return 5
+
 	default:
 		return 0
 	}
 }
 
+// Upgrade ladders used when arming in peace: next tier, its price and the tech it needs.
+// Bows sit outside the ladder, archers keep theirs
+type weaponUpgrade struct {
+	next WeaponType
+	cost Cost
+	needs Tech
+}
+
+var weaponUpgrades = map[WeaponType]weaponUpgrade{
+	WeaponNone: {next: WeaponWood, cost: Cost{Wood: 3}},
+	WeaponWood: {next: WeaponStone, cost: Cost{Stone: 3}},
+	WeaponStone: {next: WeaponIron, cost: Cost{Ore: 3}, needs: TechIronWorking},
+	WeaponIron: {next: WeaponSteel, cost: Cost{Ore: 5}, needs: TechSteelMaking},
+}
+
+type armorUpgrade struct {
+	next ArmorType
+	cost Cost
+	needs Tech
+}
+
+var armorUpgrades = map[ArmorType]armorUpgrade{
+	ArmorNone: {next: ArmorWood, cost: Cost{Wood: 5}},
+	ArmorWood: {next: ArmorStone, cost: Cost{Stone: 5}},
+	ArmorStone: {next: ArmorIron, cost: Cost{Ore: 5}, needs: TechIronWorking},
+	ArmorIron: {next: ArmorSteel, cost: Cost{Ore: 8}, needs: TechSteelMaking},
+}
+
 // Calc damage for all implicits and explicits
 func (e *Entity) TotalDamage(w *World) int {
 	baseDamage := 5
@@ -59,7 +119,7 @@ func (e *Entity) TotalDamage(w *World) int {
 		racialBonus = cfg.DamageBonus
 	}
 
-	return baseDamage + weaponBonus + racialBonus + rankBonus
+	return baseDamage + weaponBonus + racialBonus + rankBonus + e.auraDamage()
 }
 
 // Calc total armor for all implicit and explicits
@@ -74,25 +134,37 @@ func (e *Entity) TotalArmor(w *World) int {
 		racialBonus = cfg.DefenseBonus
 	}
 
-	return armorBonus + rankBonus + racialBonus
+	return armorBonus + rankBonus + racialBonus + e.auraArmor()
 }
 
-// handles clearing of tress/rocks by entity and tree regrowth (peace time only)
+// handles clearing of tress/rocks by entity (peace time only) and tree regrowth
 func HandleMiningAndRegrowth(w *World) {
 	// w.mu.Lock()
 	// defer w.mu.Unlock()
 	
-	currentTime := time.Now()
-	regrowDuration := 20 * time.Second
+	currentTick := w.tickCount
 
 	// Phase 1: Mining/Clearing (instant when entity is present on rock/tree)
-	for y := 0; y < GridSize; y++ {
-		for x := 0; x < GridSize; x++ {
-			idx := y * GridSize + x
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			idx := y*w.Width + x
 			ent := w.Entities[y][x]
 			if ent != nil {
 				terrain := TerrainType(w.Terrain[idx])
-				if terrain == TerrainTrees || terrain == TerrainRocks {
+
+				// Bushes are picked, not cleared
+				if terrain == TerrainBerries && w.berriesReady(x, y) {
+					res := w.resources[ent.Tribe]
+					if res == nil {
+						res = &TribeResources{}
+						w.resources[ent.Tribe] = res
+					}
+					res.Food += BerryFood
+					w.lastClearedTick[y][x] = currentTick
+					continue
+				}
+
+				if terrain == TerrainTrees || terrain == TerrainRocks || terrain == TerrainOre {
 					// Clear to the entity's tribe flat land
 					cfg, ok := w.Tribes[ent.Tribe]
 					if !ok {
@@ -109,41 +181,55 @@ func HandleMiningAndRegrowth(w *World) {
 						res.Wood++
 					} else if terrain == TerrainRocks {
 						res.Stone++
+					} else if terrain == TerrainOre {
+						res.Ore++
 					}
 
-					w.Terrain[y * GridSize + x] = uint8(cfg.HomeTerrain)
+					w.Terrain[y*w.Width + x] = uint8(cfg.HomeTerrain)
 
 					// Record clear time only for trees for regrowth
 					if terrain == TerrainTrees {
-						w.lastClearedTime[y][x] = currentTime
+						w.lastClearedTick[y][x] = currentTick
 					}
 				}
 			}
 		}
 	}
 
-	// Phase 2: Tree regrowth (only on cleared cells that have been empty)
-	for y := 0; y < GridSize; y++ {
-		for x := 0; x < GridSize; x++ {
-			idx := y * GridSize + x
-			if w.Entities[y][x] == nil { // Cell must be unoccupied
-				lastClear := w.lastClearedTime[y][x]
-				if !lastClear.IsZero() && currentTime.Sub(lastClear) >= regrowDuration {
-					// Regrow only if cell is flat land
+	// Phase 2: Tree regrowth
+	regrowTrees(w)
+}
+
+// Trees grow back on cleared or burnt cells that have been empty for the
+// regrow time, never in winter. Runs in peace and war, so a battle's burnt
+// ground doesn't stay bare. caller holds Mu
+func regrowTrees(w *World) {
+	currentTick := w.tickCount
+	regrowTicks, growing := w.treeRegrowTicks() // Season and weather, see seasons.go
+	if !growing {
+		return
+	}
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			idx := y*w.Width + x
+			if w.Entities[y][x] == nil && w.villages[y][x] == nil { // Cell must be unoccupied
+				lastClear := w.lastClearedTick[y][x]
+				if lastClear != 0 && currentTick - lastClear >= regrowTicks {
+					// Regrow only if cell is flat land or burnt ground
 					currentTerrain := TerrainType(w.Terrain[idx])
 					
-					isHomeFlat := false
+					canRegrow := currentTerrain == TerrainBurnt
 					for _, cfg := range w.Tribes {
 						if currentTerrain == cfg.HomeTerrain {
-							isHomeFlat = true
+							canRegrow = true
 							break
 						}
 					}
 					
-					if isHomeFlat {
-						w.Terrain[y * GridSize + x] = uint8(TerrainTrees)
+					if canRegrow {
+						w.Terrain[y*w.Width + x] = uint8(TerrainTrees)
 						// Reset timer
-						w.lastClearedTime[y][x] = time.Time{}
+						w.lastClearedTick[y][x] = 0
 					}
 				}
 			}
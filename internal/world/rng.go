@@ -0,0 +1,46 @@
+package world
+
+import (
+	"math/rand"
+)
+
+// Seeded source that counts draws, so a snapshot can fast-forward a fresh
+// source to the exact same point and the restored battle keeps replaying
+type countingSource struct {
+	src   rand.Source64
+	draws uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{src: rand.NewSource(seed).(rand.Source64)}
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.draws = 0
+}
+
+// Advances the underlying source n draws (Int63 and Uint64 both step it once)
+func (s *countingSource) skip(n uint64) {
+	for i := uint64(0); i < n; i++ {
+		s.src.Uint64()
+	}
+	s.draws += n
+}
+
+// Rebuilds the world rng from its seed, fast-forwarded by draws
+func (w *World) resetRNG(draws uint64) {
+	w.rngSrc = newCountingSource(w.seed)
+	w.rngSrc.skip(draws)
+	w.rng = rand.New(w.rngSrc)
+}
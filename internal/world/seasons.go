@@ -0,0 +1,167 @@
+package world
+
+// Seasons and weather. The year turns every SeasonTicks, straight from the
+// tick count so replays and snapshots always agree on it. Weather is rolled
+// every WeatherTicks with odds that depend on the season. Spring brings more
+// births, winter stops trees regrowing, cuts births and makes rough terrain
+// harsher, rain speeds regrowth up and storms halve movement and add to the
+// attrition
+const (
+	SeasonTicks  = 600 // 2.5 minutes at 1x, a year is 4 of them
+	WeatherTicks = 100
+
+	BaseAttrition    = 3 // Health lost per war tick on hills, rocks, trees and ore
+	WinterAttrition  = 2 // Extra in winter
+	StormAttrition   = 1 // Extra in a storm
+	SpringBirthBoost = 1.5
+	WinterBirthCut   = 0.5
+	StormMoveCut     = 0.5
+)
+
+type Season uint8
+
+const (
+	SeasonSpring Season = iota
+	SeasonSummer
+	SeasonAutumn
+	SeasonWinter
+)
+
+func (s Season) String() string {
+	switch s {
+	case SeasonSpring:
+		return "Spring"
+
+	case SeasonSummer:
+		return "Summer"
+
+	case SeasonAutumn:
+		return "Autumn"
+
+	case SeasonWinter:
+		return "Winter"
+
+	default:
+		return "Unknown"
+	}
+}
+
+type Weather uint8
+
+const (
+	WeatherClear Weather = iota
+	WeatherRain
+	WeatherStorm
+)
+
+func (wt Weather) String() string {
+	switch wt {
+	case WeatherClear:
+		return "Clear"
+
+	case WeatherRain:
+		return "Rain"
+
+	case WeatherStorm:
+		return "Storm"
+
+	default:
+		return "Unknown"
+	}
+}
+
+// Chance of rain and of a storm per season, clear skies otherwise
+var weatherOdds = map[Season][2]float64{
+	SeasonSpring: {0.40, 0.10},
+	SeasonSummer: {0.15, 0.15},
+	SeasonAutumn: {0.35, 0.20},
+	SeasonWinter: {0.20, 0.20},
+}
+
+// caller holds Mu
+func (w *World) season() Season {
+	return Season(w.tickCount / SeasonTicks % 4)
+}
+
+// Rolls the weather every WeatherTicks, call once per tick after tickCount
+// moves on. caller holds Mu
+func (w *World) updateWeather() {
+	if w.tickCount%WeatherTicks != 0 {
+		return
+	}
+
+	odds := weatherOdds[w.season()]
+	roll := w.rng.Float64()
+	switch {
+	case roll < odds[0]:
+		w.weather = WeatherRain
+
+	case roll < odds[0]+odds[1]:
+		w.weather = WeatherStorm
+
+	default:
+		w.weather = WeatherClear
+	}
+}
+
+// Chance a unit tries to move this tick, caller holds Mu
+func (w *World) moveChance() float64 {
+	if w.weather == WeatherStorm {
+		return w.EntityStats.MoveChance * StormMoveCut
+	}
+
+	return w.EntityStats.MoveChance
+}
+
+// Chance a unit near a village breeds this tick, before the village level,
+// caller holds Mu
+func (w *World) birthRate() float64 {
+	switch w.season() {
+	case SeasonSpring:
+		return w.EntityStats.ReproductionRate * SpringBirthBoost
+
+	case SeasonWinter:
+		return w.EntityStats.ReproductionRate * WinterBirthCut
+
+	default:
+		return w.EntityStats.ReproductionRate
+	}
+}
+
+// Health lost per war tick on rough terrain, caller holds Mu
+func (w *World) attrition() int {
+	dmg := BaseAttrition
+	if w.season() == SeasonWinter {
+		dmg += WinterAttrition
+	}
+	if w.weather == WeatherStorm {
+		dmg += StormAttrition
+	}
+
+	return dmg
+}
+
+// Ticks before a cleared tree grows back, ok is false in winter when nothing
+// grows. caller holds Mu
+func (w *World) treeRegrowTicks() (ticks int64, ok bool) {
+	if w.season() == SeasonWinter {
+		return 0, false
+	}
+	if w.weather == WeatherRain {
+		return w.regrowTicks / 2, true
+	}
+
+	return w.regrowTicks, true
+}
+
+func (w *World) Season() Season {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+	return w.season()
+}
+
+func (w *World) Weather() Weather {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+	return w.weather
+}
@@ -0,0 +1,100 @@
+package world
+
+import "testing"
+
+func TestSeasonFollowsTick(t *testing.T) {
+	w := newTestWorld(t, 1, "rr", "")
+	for _, tc := range []struct {
+		tick int64
+		want Season
+	}{
+		{0, SeasonSpring},
+		{SeasonTicks - 1, SeasonSpring},
+		{SeasonTicks, SeasonSummer},
+		{3 * SeasonTicks, SeasonWinter},
+		{4 * SeasonTicks, SeasonSpring},
+	} {
+		w.tickCount = tc.tick
+		if got := w.season(); got != tc.want {
+			t.Fatalf("tick %d: %v, want %v", tc.tick, got, tc.want)
+		}
+	}
+}
+
+func TestWinterStopsTreeRegrowth(t *testing.T) {
+	w := newTestWorld(t, 1, "rr", "")
+	w.tickCount = 3 * SeasonTicks
+	w.lastClearedTick[0][0] = 1
+
+	HandleMiningAndRegrowth(w)
+	if TerrainType(w.Terrain[0]) == TerrainTrees {
+		t.Fatal("tree regrew in winter")
+	}
+
+	w.tickCount = 4 * SeasonTicks
+	HandleMiningAndRegrowth(w)
+	if TerrainType(w.Terrain[0]) != TerrainTrees {
+		t.Fatal("tree didn't regrow in spring")
+	}
+}
+
+func TestRainSpeedsRegrowth(t *testing.T) {
+	w := newTestWorld(t, 1, "rr", "")
+	w.tickCount = w.regrowTicks/2 + 1
+	w.lastClearedTick[0][0] = 1
+	w.weather = WeatherRain
+
+	HandleMiningAndRegrowth(w)
+	if TerrainType(w.Terrain[0]) != TerrainTrees {
+		t.Fatal("tree didn't regrow early in the rain")
+	}
+}
+
+func TestWinterStormAttrition(t *testing.T) {
+	w := newTestWorld(t, 1, `
+hrb
+`, `
+1.2
+`)
+	w.EntityStats.MoveChance = 0
+	ent := w.Entities[0][0]
+	w.tickCount = 3 * SeasonTicks
+	w.weather = WeatherStorm
+	w.StartWar()
+
+	w.Update()
+	// Minimum hit of 1, then attrition
+	if want := 100 - 1 - (BaseAttrition + WinterAttrition + StormAttrition); ent.Health != want {
+		t.Fatalf("health %d, want %d", ent.Health, want)
+	}
+}
+
+func TestSeasonalRates(t *testing.T) {
+	w := newTestWorld(t, 1, "rr", "")
+	w.EntityStats.MoveChance = 0.2
+	w.EntityStats.ReproductionRate = 0.01
+
+	if got := w.birthRate(); got != 0.01*SpringBirthBoost {
+		t.Fatalf("spring birth rate %v", got)
+	}
+	w.tickCount = 3 * SeasonTicks
+	if got := w.birthRate(); got != 0.01*WinterBirthCut {
+		t.Fatalf("winter birth rate %v", got)
+	}
+
+	w.weather = WeatherStorm
+	if got := w.moveChance(); got != 0.2*StormMoveCut {
+		t.Fatalf("storm move chance %v", got)
+	}
+}
+
+func TestSnapshotKeepsWeather(t *testing.T) {
+	w := newMapWorld(t, 7, "vertical", 30, 30)
+	w.weather = WeatherStorm
+
+	restored := roundTrip(t, w)
+
+	if got := restored.Weather(); got != WeatherStorm {
+		t.Fatalf("weather %v after restore, want Storm", got)
+	}
+}
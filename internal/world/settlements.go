@@ -0,0 +1,267 @@
+package world
+
+// Villages are buildings on a tribe's home terrain. Entities only reproduce
+// near one of their own, faster the higher its level, and a tribe that has
+// outgrown its villages saves resources (no arming) to found or upgrade one
+const (
+	VillageVizCode uint8 = 13 // Grid code sent to clients, entities draw on top
+
+	VillageFoundWood    int64 = 10
+	VillageFoundStone   int64 = 5
+	VillageUpgradeWood  int64 = 15 // Per current level
+	VillageUpgradeStone int64 = 5  // Per current level
+
+	MaxVillageLevel = 3
+	VillageCapacity = 15 // Entities each village level supports before the tribe wants more
+	VillageRadius   = 6  // Reproduction only within this many cells of an own village
+	VillageSpacing  = 8  // No new village this close to any other
+)
+
+type Village struct {
+	Tribe uint8
+	Level int // 1..MaxVillageLevel
+}
+
+func newVillageLayer(width, height int) [][]*Village {
+	layer := make([][]*Village, height)
+	for i := range layer {
+		layer[i] = make([]*Village, width)
+	}
+
+	return layer
+}
+
+// Upgrade cost from the current level to the next
+func (v *Village) upgradeCost() (wood, stone int64) {
+	return VillageUpgradeWood * int64(v.Level), VillageUpgradeStone * int64(v.Level)
+}
+
+// Highest level of tribe's villages within VillageRadius of (x, y), 0 if none
+func (w *World) nearVillageLevel(x, y int, tribe uint8) int {
+	best := 0
+	for vy := y - VillageRadius; vy <= y+VillageRadius; vy++ {
+		if vy < 0 || vy >= w.Height {
+			continue
+		}
+		for vx := x - VillageRadius; vx <= x+VillageRadius; vx++ {
+			if vx < 0 || vx >= w.Width {
+				continue
+			}
+			if v := w.villages[vy][vx]; v != nil && v.Tribe == tribe && v.Level > best {
+				best = v.Level
+			}
+		}
+	}
+
+	return best
+}
+
+// True if no village of any tribe is within VillageSpacing of (x, y)
+func (w *World) villageSiteFree(x, y int) bool {
+	for vy := y - VillageSpacing; vy <= y+VillageSpacing; vy++ {
+		if vy < 0 || vy >= w.Height {
+			continue
+		}
+		for vx := x - VillageSpacing; vx <= x+VillageSpacing; vx++ {
+			if vx >= 0 && vx < w.Width && w.villages[vy][vx] != nil {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// One village at each tribe's first starter so reproduction can begin, caller holds Mu
+func (w *World) placeStartingVillages() {
+	for _, tribe := range w.sortedTribeIDs() {
+		pos, ok := w.firstEntityOf(tribe)
+		if !ok {
+			continue
+		}
+		w.villages[pos[1]][pos[0]] = &Village{Tribe: tribe, Level: 1}
+	}
+}
+
+// Tribes with no unit, village or passenger anywhere, caller holds Mu
+func (w *World) unsettledTribes() map[uint8]bool {
+	unsettled := make(map[uint8]bool)
+	for tribe := range w.Tribes {
+		unsettled[tribe] = true
+	}
+
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if v := w.villages[y][x]; v != nil {
+				delete(unsettled, v.Tribe)
+			}
+			if ent := w.Entities[y][x]; ent != nil {
+				delete(unsettled, ent.Tribe)
+			}
+		}
+	}
+	w.eachPassenger(func(ent *Entity) {
+		delete(unsettled, ent.Tribe)
+	})
+
+	return unsettled
+}
+
+// Starting village for a tribe that was unsettled before a batch, at pos
+// where its first unit went. If the batch painted over that unit, at its
+// lowest-ID unit instead. caller holds Mu
+func (w *World) settleFirstUnit(tribe uint8, pos [2]int) {
+	if ent := w.Entities[pos[1]][pos[0]]; ent == nil || ent.Tribe != tribe {
+		var ok bool
+		if pos, ok = w.firstEntityOf(tribe); !ok {
+			return
+		}
+	}
+
+	w.villages[pos[1]][pos[0]] = &Village{Tribe: tribe, Level: 1}
+}
+
+// Position of the tribe's lowest-ID entity
+func (w *World) firstEntityOf(tribe uint8) ([2]int, bool) {
+	var pos [2]int
+	var bestID uint32
+	found := false
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			ent := w.Entities[y][x]
+			if ent != nil && ent.Tribe == tribe && (!found || ent.ID < bestID) {
+				pos, bestID, found = [2]int{x, y}, ent.ID, true
+			}
+		}
+	}
+
+	return pos, found
+}
+
+// Peace-time settlement step on the post-move entity layer. Tribes at capacity
+// found a village (or upgrade their smallest) once they can pay for it.
+// Returns the tribes still saving up wood, they skip arming and research this
+// tick. Being short on stone alone doesn't count, holding back spending won't
+// bring more of it and rocks don't grow back
+func (w *World) growSettlements(ents [][]*Entity, tribeCounts map[uint8]int) map[uint8]bool {
+	capacity := make(map[uint8]int)
+	smallest := make(map[uint8]*Village)
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			v := w.villages[y][x]
+			if v == nil {
+				continue
+			}
+			capacity[v.Tribe] += v.Level * VillageCapacity
+			if s := smallest[v.Tribe]; s == nil || v.Level < s.Level {
+				smallest[v.Tribe] = v
+			}
+		}
+	}
+
+	saving := make(map[uint8]bool)
+	for _, tribe := range w.sortedTribeIDs() {
+		if tribeCounts[tribe] < capacity[tribe] {
+			continue
+		}
+
+		res := w.resources[tribe]
+		if res == nil {
+			res = &TribeResources{}
+			w.resources[tribe] = res
+		}
+		cfg := w.Tribes[tribe]
+
+		// Found a new village on a free spot under one of our entities
+		if res.Wood >= VillageFoundWood && res.Stone >= VillageFoundStone {
+			sites := [][2]int{}
+			for y := 0; y < w.Height; y++ {
+				for x := 0; x < w.Width; x++ {
+					ent := ents[y][x]
+					if ent != nil && ent.Tribe == tribe && TerrainType(w.Terrain[y*w.Width+x]) == cfg.HomeTerrain && w.villageSiteFree(x, y) {
+						sites = append(sites, [2]int{x, y})
+					}
+				}
+			}
+
+			if len(sites) > 0 {
+				site := sites[w.rng.Intn(len(sites))]
+				w.villages[site[1]][site[0]] = &Village{Tribe: tribe, Level: 1}
+				res.Wood -= VillageFoundWood
+				res.Stone -= VillageFoundStone
+				continue
+			}
+		}
+
+		// No room (or money) for a new one, grow the smallest instead
+		if v := smallest[tribe]; v != nil && v.Level < MaxVillageLevel {
+			wood, stone := v.upgradeCost()
+			if res.Wood >= wood && res.Stone >= stone {
+				res.Wood -= wood
+				res.Stone -= stone
+				v.Level++
+				continue
+			}
+		}
+
+		if res.Wood < VillageFoundWood {
+			saving[tribe] = true
+		}
+	}
+
+	return saving
+}
+
+// War step: a village with an enemy (at war with its owner) standing on it may fall, losing a level
+// and changing hands. Level 1 villages are razed instead
+func (w *World) contestVillages(ents [][]*Entity) {
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			v := w.villages[y][x]
+			ent := ents[y][x]
+			if v == nil || ent == nil || !w.hostile(ent.Tribe, v.Tribe) {
+				continue
+			}
+			if w.rng.Float64() >= w.conversionRate {
+				continue
+			}
+
+			if v.Level <= 1 {
+				w.villages[y][x] = nil
+			} else {
+				v.Level--
+				v.Tribe = ent.Tribe
+			}
+		}
+	}
+}
+
+// Village count per tribe
+func (w *World) CountVillagesByTribe() map[uint8]int {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+
+	counts := make(map[uint8]int)
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if v := w.villages[y][x]; v != nil {
+				counts[v.Tribe]++
+			}
+		}
+	}
+
+	return counts
+}
+
+// Village at (x, y), nil if none. Returns a copy
+func (w *World) GetVillage(x, y int) *Village {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+
+	if x < 0 || x >= w.Width || y < 0 || y >= w.Height || w.villages[y][x] == nil {
+		return nil
+	}
+
+	v := *w.villages[y][x]
+	return &v
+}
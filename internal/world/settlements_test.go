@@ -0,0 +1,130 @@
+package world
+
+import "testing"
+
+// Lone entity that would breed every tick, if it's allowed to at all
+func breedingWorld(t *testing.T) *World {
+	t.Helper()
+
+	w := newTestWorld(t, 1, "rrrrr", "..1..")
+	w.EntityStats.MoveChance = 0
+	w.EntityStats.ReproductionRate = 1
+	w.EntityStats.MaxDensityFraction = 1
+	w.resources[1] = &TribeResources{Food: 1000}
+	return w
+}
+
+func TestNoReproductionWithoutVillage(t *testing.T) {
+	w := breedingWorld(t)
+	for i := 0; i < 20; i++ {
+		w.PreWarUpdate()
+	}
+
+	if n := len(entityPositions(w)); n != 1 {
+		t.Fatalf("%d entities without a village, want 1", n)
+	}
+}
+
+func TestReproductionNearVillage(t *testing.T) {
+	w := breedingWorld(t)
+	w.villages[0][2] = &Village{Tribe: 1, Level: 1}
+	for i := 0; i < 20; i++ {
+		w.PreWarUpdate()
+	}
+
+	if n := len(entityPositions(w)); n < 2 {
+		t.Fatalf("%d entities next to a village, want growth", n)
+	}
+}
+
+func TestFoundVillageWhenAffordable(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr", ".1.")
+	w.EntityStats.MoveChance = 0
+	w.resources[1] = &TribeResources{Wood: VillageFoundWood, Stone: VillageFoundStone}
+	w.PreWarUpdate()
+
+	v := w.GetVillage(1, 0)
+	if v == nil || v.Tribe != 1 || v.Level != 1 {
+		t.Fatalf("village under the entity is %+v, want a new tribe 1 village", v)
+	}
+}
+
+// Enemy on a village with conversion certain. Tribe 2 is walled off so the war goes on
+func contestWorld(t *testing.T, level int) *World {
+	t.Helper()
+
+	w := newTestWorld(t, 1, "bb#bb", "1#..2")
+	w.EntityStats.MoveChance = 0
+	w.villages[0][0] = &Village{Tribe: 2, Level: level}
+	w.StartWar()
+	w.conversionRate = 1
+	return w
+}
+
+func TestVillageCapturedInWar(t *testing.T) {
+	w := contestWorld(t, 2)
+	w.Update()
+
+	v := w.GetVillage(0, 0)
+	if v == nil || v.Tribe != 1 || v.Level != 1 {
+		t.Fatalf("contested village is %+v, want tribe 1 at level 1", v)
+	}
+}
+
+func TestVillageRazedInWar(t *testing.T) {
+	w := contestWorld(t, 1)
+	w.Update()
+
+	if v := w.GetVillage(0, 0); v != nil {
+		t.Fatalf("level 1 village survived capture as %+v", v)
+	}
+}
+
+func TestSnapshotKeepsVillages(t *testing.T) {
+	w := newMapWorld(t, 7, "fourquadrants", 30, 30)
+	restored := roundTrip(t, w)
+
+	want, got := w.CountVillagesByTribe(), restored.CountVillagesByTribe()
+	if len(want) != 4 {
+		t.Fatalf("%d tribes with a starting village, want 4", len(want))
+	}
+	for tribe, n := range want {
+		if got[tribe] != n {
+			t.Fatalf("tribe %d has %d villages after restore, want %d", tribe, got[tribe], n)
+		}
+	}
+}
+
+func TestFirstPlacedUnitGetsVillageAfterReset(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr.bb", "1.....")
+	w.villages[0][0] = &Village{Tribe: 1, Level: 1}
+	w.Reset()
+
+	if !w.PlaceEntity(1, 0, 3) || !w.PlaceEntity(2, 0, 3) || !w.PlaceEntity(5, 0, 3) {
+		t.Fatal("placing failed")
+	}
+	if v := w.GetVillage(1, 0); v == nil || v.Tribe != 1 {
+		t.Fatalf("village under tribe 1's first unit is %+v", v)
+	}
+	if w.GetVillage(2, 0) != nil {
+		t.Fatal("second unit got a village too")
+	}
+	if v := w.GetVillage(5, 0); v == nil || v.Tribe != 2 {
+		t.Fatalf("village under tribe 2's first unit is %+v", v)
+	}
+}
+
+func TestPlaceBatchSettlesOncePerTribe(t *testing.T) {
+	w := newTestWorld(t, 1, "rrr.bbb", ".......")
+	w.villages[0][6] = &Village{Tribe: 2, Level: 1}
+
+	if !w.PlaceBatch([]Placement{{X: 1, Y: 0, Type: 3}, {X: 2, Y: 0, Type: 3}, {X: 4, Y: 0, Type: 3}}) {
+		t.Fatal("batch placed nothing")
+	}
+	if v := w.GetVillage(1, 0); v == nil || v.Tribe != 1 {
+		t.Fatalf("village under tribe 1's first unit is %+v", v)
+	}
+	if w.GetVillage(2, 0) != nil || w.GetVillage(4, 0) != nil {
+		t.Fatal("a unit that wasn't its unsettled tribe's first got a village")
+	}
+}
@@ -0,0 +1,375 @@
+package world
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Bump when a change can't be read by older Restore code. Restore accepts
+// any version up to this one, fields missing from older files load as zero
+//
+//	1: fixed square grid (gridSize)
+//	2: per-world width/height
+//	3: villages
+//	4: food
+//	5: ore and tech research
+//	6: diplomacy
+//	7: morale
+//	8: birth ticks
+//	9: leaders
+//	10: boats
+//	11: weather
+//	12: flow fields
+const SnapshotVersion = 12
+
+type snapshotEntity struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Entity
+}
+
+type snapshotVillage struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	Village
+}
+
+type snapshotBoat struct {
+	X          int      `json:"x"`
+	Y          int      `json:"y"`
+	Tribe      uint8    `json:"tribe"`
+	Passengers []Entity `json:"passengers"`
+}
+
+type snapshotRelation struct {
+	A        uint8    `json:"a"`
+	B        uint8    `json:"b"`
+	Relation Relation `json:"relation"`
+}
+
+type snapshotCellTick struct {
+	X    int   `json:"x"`
+	Y    int   `json:"y"`
+	Tick int64 `json:"tick"`
+}
+
+// The flow fields as they stood, a field is only rebuilt every PathTicks so a
+// restored world needs the same ones to move as the original would. A nil
+// set (older snapshots, or dropped) is rebuilt on the next tick
+type snapshotFlowFields struct {
+	Home    map[uint8]flowField `json:"home"`
+	Attack  map[uint8]flowField `json:"attack"`
+	Landing map[uint8]flowField `json:"landing"`
+	Dock    map[uint8]flowField `json:"dock"`
+	Embark  map[uint8]flowField `json:"embark"`
+}
+
+// On-disk form of a World, cooldown layers are stored sparse
+type snapshot struct {
+	Version        int                      `json:"version"`
+	GridSize       int                      `json:"gridSize,omitempty"` // v1 only, square
+	Width          int                      `json:"width"`
+	Height         int                      `json:"height"`
+	Seed           int64                    `json:"seed"`
+	RNGDraws       uint64                   `json:"rngDraws"`
+	Tick           int64                    `json:"tick"`
+	Terrain        []uint8                  `json:"terrain"`
+	Entities       []snapshotEntity         `json:"entities"`
+	ReprodTicks    []snapshotCellTick       `json:"reprodTicks"`
+	ClearedTicks   []snapshotCellTick       `json:"clearedTicks"`
+	Villages       []snapshotVillage        `json:"villages"`
+	Boats          []snapshotBoat           `json:"boats"`
+	Resources      map[uint8]TribeResources `json:"resources"`
+	Research       map[uint8]Research       `json:"research"`
+	Relations      []snapshotRelation       `json:"relations"`
+	DiplomacyAI    bool                     `json:"diplomacyAI"`
+	LeaderFell     map[uint8]int64          `json:"leaderFell"`
+	NextEntityID   map[uint8]uint32         `json:"nextEntityID"`
+	Tribes         map[uint8]TribeConfig    `json:"tribes"`
+	WarStarted     bool                     `json:"warStarted"`
+	GameOver       bool                     `json:"gameOver"`
+	Winner         string                   `json:"winner"`
+	EntityStats    EntityStats              `json:"entityStats"`
+	ConversionRate float64                  `json:"conversionRate"`
+	RegrowTicks    int64                    `json:"regrowTicks"`
+	Weather        Weather                  `json:"weather"`
+	FlowFields     snapshotFlowFields       `json:"flowFields"`
+}
+
+// Writes the full world state (terrain, entities, resources, war state, rng position)
+func (w *World) Snapshot(out io.Writer) error {
+	w.Mu.RLock()
+	snap := snapshot{
+		Version:        SnapshotVersion,
+		Width:          w.Width,
+		Height:         w.Height,
+		Seed:           w.seed,
+		RNGDraws:       w.rngSrc.draws,
+		Tick:           w.tickCount,
+		Terrain:        append([]uint8(nil), w.Terrain...),
+		Resources:      make(map[uint8]TribeResources),
+		Research:       make(map[uint8]Research),
+		NextEntityID:   make(map[uint8]uint32),
+		Tribes:         make(map[uint8]TribeConfig),
+		WarStarted:     w.warStarted,
+		GameOver:       w.gameOver,
+		Winner:         w.winner,
+		EntityStats:    w.EntityStats,
+		ConversionRate: w.conversionRate,
+		RegrowTicks:    w.regrowTicks,
+		Weather:        w.weather,
+		DiplomacyAI:    w.diplomacyAI,
+		LeaderFell:     make(map[uint8]int64),
+		FlowFields: snapshotFlowFields{
+			Home:    copyFlowFields(w.homeFields),
+			Attack:  copyFlowFields(w.attackFields),
+			Landing: copyFlowFields(w.landingFields),
+			Dock:    copyFlowFields(w.dockFields),
+			Embark:  copyFlowFields(w.embarkFields),
+		},
+	}
+
+	for y := 0; y < w.Height; y++ {
+		for x := 0; x < w.Width; x++ {
+			if ent := w.Entities[y][x]; ent != nil {
+				snap.Entities = append(snap.Entities, snapshotEntity{X: x, Y: y, Entity: *ent})
+			}
+			if t := w.lastReprodTick[y][x]; t != 0 {
+				snap.ReprodTicks = append(snap.ReprodTicks, snapshotCellTick{X: x, Y: y, Tick: t})
+			}
+			if t := w.lastClearedTick[y][x]; t != 0 {
+				snap.ClearedTicks = append(snap.ClearedTicks, snapshotCellTick{X: x, Y: y, Tick: t})
+			}
+			if v := w.villages[y][x]; v != nil {
+				snap.Villages = append(snap.Villages, snapshotVillage{X: x, Y: y, Village: *v})
+			}
+			if b := w.boats[y][x]; b != nil {
+				sb := snapshotBoat{X: x, Y: y, Tribe: b.Tribe}
+				for _, ent := range b.Passengers {
+					sb.Passengers = append(sb.Passengers, *ent)
+				}
+				snap.Boats = append(snap.Boats, sb)
+			}
+		}
+	}
+
+	for tribe, res := range w.resources {
+		snap.Resources[tribe] = *res
+	}
+	for key, r := range w.relations {
+		snap.Relations = append(snap.Relations, snapshotRelation{A: key[0], B: key[1], Relation: r})
+	}
+	for tribe, r := range w.research {
+		snap.Research[tribe] = Research{Known: append([]Tech(nil), r.Known...), Current: r.Current, Progress: r.Progress}
+	}
+	for tribe, counter := range w.nextEntityID {
+		snap.NextEntityID[tribe] = *counter
+	}
+	for tribe, tick := range w.leaderFell {
+		snap.LeaderFell[tribe] = tick
+	}
+	for tribe, cfg := range w.Tribes {
+		snap.Tribes[tribe] = cfg
+	}
+	w.Mu.RUnlock()
+
+	return json.NewEncoder(out).Encode(snap)
+}
+
+// Fields are never changed once built, only the maps holding them need
+// copying before Mu is released. caller holds Mu
+func copyFlowFields(fields map[uint8]flowField) map[uint8]flowField {
+	if fields == nil {
+		return nil
+	}
+
+	c := make(map[uint8]flowField, len(fields))
+	for tribe, f := range fields {
+		c[tribe] = f
+	}
+
+	return c
+}
+
+// Replaces the whole world state with a snapshot. The world is left
+// untouched if the snapshot is invalid
+func (w *World) Restore(in io.Reader) error {
+	var snap snapshot
+	if err := json.NewDecoder(in).Decode(&snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+
+	if snap.Version < 1 || snap.Version > SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d (want 1..%d)", snap.Version, SnapshotVersion)
+	}
+	width, height := snap.Width, snap.Height
+	if snap.Version == 1 {
+		width, height = snap.GridSize, snap.GridSize
+	}
+	if err := ValidGridSize(width, height); err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	if len(snap.Terrain) != width*height {
+		return fmt.Errorf("snapshot terrain has %d cells, want %d", len(snap.Terrain), width*height)
+	}
+
+	inBounds := func(x, y int) bool {
+		return x >= 0 && x < width && y >= 0 && y < height
+	}
+
+	entities := newEntityLayer(width, height)
+	for _, se := range snap.Entities {
+		if !inBounds(se.X, se.Y) {
+			return fmt.Errorf("snapshot entity at (%d,%d) out of bounds", se.X, se.Y)
+		}
+		if entities[se.Y][se.X] != nil {
+			return fmt.Errorf("snapshot has two entities at (%d,%d)", se.X, se.Y)
+		}
+		ent := se.Entity
+		if snap.Version < 7 {
+			ent.Morale = MaxMorale // Older units start steady instead of routed
+		}
+		if snap.Version < 8 {
+			ent.Born = snap.Tick // Not born at tick 0, or a long game would die of old age on load
+		}
+		entities[se.Y][se.X] = &ent
+	}
+
+	reprod := newTickLayer(width, height)
+	cleared := newTickLayer(width, height)
+	for _, ct := range snap.ReprodTicks {
+		if !inBounds(ct.X, ct.Y) {
+			return fmt.Errorf("snapshot cooldown at (%d,%d) out of bounds", ct.X, ct.Y)
+		}
+		reprod[ct.Y][ct.X] = ct.Tick
+	}
+	for _, ct := range snap.ClearedTicks {
+		if !inBounds(ct.X, ct.Y) {
+			return fmt.Errorf("snapshot regrowth at (%d,%d) out of bounds", ct.X, ct.Y)
+		}
+		cleared[ct.Y][ct.X] = ct.Tick
+	}
+
+	villages := newVillageLayer(width, height)
+	for _, sv := range snap.Villages {
+		if !inBounds(sv.X, sv.Y) {
+			return fmt.Errorf("snapshot village at (%d,%d) out of bounds", sv.X, sv.Y)
+		}
+		if sv.Level < 1 || sv.Level > MaxVillageLevel {
+			return fmt.Errorf("snapshot village at (%d,%d) has level %d", sv.X, sv.Y, sv.Level)
+		}
+		v := sv.Village
+		villages[sv.Y][sv.X] = &v
+	}
+
+	boats := newBoatLayer(width, height)
+	for _, sb := range snap.Boats {
+		if !inBounds(sb.X, sb.Y) {
+			return fmt.Errorf("snapshot boat at (%d,%d) out of bounds", sb.X, sb.Y)
+		}
+		if boats[sb.Y][sb.X] != nil {
+			return fmt.Errorf("snapshot has two boats at (%d,%d)", sb.X, sb.Y)
+		}
+		if len(sb.Passengers) > BoatCapacity {
+			return fmt.Errorf("snapshot boat at (%d,%d) carries %d units", sb.X, sb.Y, len(sb.Passengers))
+		}
+		b := &Boat{Tribe: sb.Tribe}
+		for i := range sb.Passengers {
+			b.Passengers = append(b.Passengers, &sb.Passengers[i])
+		}
+		boats[sb.Y][sb.X] = b
+	}
+
+	for tribe, r := range snap.Research {
+		for _, t := range r.Known {
+			if _, ok := techTree[t]; !ok {
+				return fmt.Errorf("snapshot tribe %d knows unknown tech %d", tribe, t)
+			}
+		}
+		if _, ok := techTree[r.Current]; !ok && r.Current != TechNone {
+			return fmt.Errorf("snapshot tribe %d researches unknown tech %d", tribe, r.Current)
+		}
+	}
+
+	relations := make(map[[2]uint8]Relation)
+	for _, sr := range snap.Relations {
+		if sr.A == sr.B || sr.Relation > RelationAlliance {
+			return fmt.Errorf("snapshot has bad relation %d between tribes %d and %d", sr.Relation, sr.A, sr.B)
+		}
+		if sr.Relation != RelationWar {
+			relations[relationKey(sr.A, sr.B)] = sr.Relation
+		}
+	}
+
+	ff := snap.FlowFields
+	for _, fields := range []map[uint8]flowField{ff.Home, ff.Attack, ff.Landing, ff.Dock, ff.Embark} {
+		for tribe, f := range fields {
+			if len(f) != width*height {
+				return fmt.Errorf("snapshot flow field for tribe %d has %d cells, want %d", tribe, len(f), width*height)
+			}
+		}
+	}
+
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+
+	w.seed = snap.Seed
+	w.resetRNG(snap.RNGDraws)
+	w.tickCount = snap.Tick
+	w.Width = width
+	w.Height = height
+	w.Terrain = snap.Terrain
+	w.Entities = entities
+	w.lastReprodTick = reprod
+	w.lastClearedTick = cleared
+	w.villages = villages
+	w.boats = boats
+	w.homeFields, w.attackFields = ff.Home, ff.Attack
+	w.landingFields, w.dockFields, w.embarkFields = ff.Landing, ff.Dock, ff.Embark
+
+	w.resources = make(map[uint8]*TribeResources)
+	for tribe, res := range snap.Resources {
+		r := res
+		w.resources[tribe] = &r
+	}
+	w.research = make(map[uint8]*Research)
+	for tribe, r := range snap.Research {
+		rr := r
+		w.research[tribe] = &rr
+	}
+	w.relations = relations
+	w.diplomacyAI = snap.DiplomacyAI
+	w.leaderFell = make(map[uint8]int64)
+	for tribe, tick := range snap.LeaderFell {
+		w.leaderFell[tribe] = tick
+	}
+	w.nextEntityID = make(map[uint8]*uint32)
+	for tribe, next := range snap.NextEntityID {
+		n := next
+		w.nextEntityID[tribe] = &n
+	}
+	w.Tribes = snap.Tribes
+	if w.Tribes == nil {
+		w.Tribes = make(map[uint8]TribeConfig)
+	}
+
+	w.warStarted = snap.WarStarted
+	w.gameOver = snap.GameOver
+	w.winner = snap.Winner
+	w.EntityStats = snap.EntityStats
+	w.conversionRate = snap.ConversionRate
+	w.regrowTicks = snap.RegrowTicks
+	w.weather = snap.Weather
+
+	// Older snapshots predate villages, give each tribe one so it can still grow
+	if snap.Version < 3 {
+		w.placeStartingVillages()
+	}
+	// Same for food, an empty larder would stall reproduction until they forage
+	if snap.Version < 4 {
+		w.stockStartingFood()
+	}
+
+	return nil
+}
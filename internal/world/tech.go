@@ -0,0 +1,172 @@
+package world
+
+// Per-tribe tech tree. Research takes Ticks peace ticks to finish and pays its
+// cost in installments along the way, pausing on any tick the tribe can't pay.
+// Iron and steel gear need their tech and ore, which is mined from TerrainOre
+// like stone from rocks
+type Tech uint8
+
+const (
+	TechNone        Tech = 0 // Always known, gates the wood/stone tiers
+	TechIronWorking Tech = 1
+	TechSteelMaking Tech = 2
+)
+
+// Resources a purchase takes out of TribeResources
+type Cost struct {
+	Wood  int64
+	Stone int64
+	Ore   int64
+	Food  int64
+}
+
+func (r *TribeResources) canPay(c Cost) bool {
+	return r.Wood >= c.Wood && r.Stone >= c.Stone && r.Ore >= c.Ore && r.Food >= c.Food
+}
+
+func (r *TribeResources) pay(c Cost) {
+	r.Wood -= c.Wood
+	r.Stone -= c.Stone
+	r.Ore -= c.Ore
+	r.Food -= c.Food
+}
+
+type techInfo struct {
+	Name     string
+	Requires Tech
+	Cost     Cost
+	Ticks    int64
+}
+
+var techTree = map[Tech]techInfo{
+	TechIronWorking: {Name: "Iron Working", Requires: TechNone, Cost: Cost{Wood: 40, Ore: 10}, Ticks: 300},
+	TechSteelMaking: {Name: "Steel Making", Requires: TechIronWorking, Cost: Cost{Wood: 80, Ore: 20}, Ticks: 600},
+}
+
+// Research order, each tribe works down this list
+var techOrder = []Tech{TechIronWorking, TechSteelMaking}
+
+func (t Tech) String() string {
+	if info, ok := techTree[t]; ok {
+		return info.Name
+	}
+
+	return "None"
+}
+
+// A tribe's progress through the tree
+type Research struct {
+	Known    []Tech `json:"known"`    // In the order they were finished
+	Current  Tech   `json:"current"`  // TechNone when idle
+	Progress int64  `json:"progress"` // Ticks paid for on Current
+}
+
+func (r *Research) knows(t Tech) bool {
+	if t == TechNone {
+		return true
+	}
+	for _, k := range r.Known {
+		if k == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// True if tribe has finished t, caller holds Mu
+func (w *World) hasTech(tribe uint8, t Tech) bool {
+	if t == TechNone {
+		return true
+	}
+	r := w.research[tribe]
+	return r != nil && r.knows(t)
+}
+
+// Share of c due on tick p (from 0) of a research taking ticks, the shares
+// add up to exactly c
+func installment(c Cost, p, ticks int64) Cost {
+	share := func(total int64) int64 {
+		return total*(p+1)/ticks - total*p/ticks
+	}
+
+	return Cost{Wood: share(c.Wood), Stone: share(c.Stone), Ore: share(c.Ore), Food: share(c.Food)}
+}
+
+// Peace-time research step. Running research pays this tick's installment
+// and advances, or waits a tick if the tribe can't pay it. It runs before
+// arming, so new stock goes to research first. Idle tribes that aren't
+// saving for a village start the next tech
+func (w *World) advanceResearch(saving map[uint8]bool) {
+	for _, tribe := range w.sortedTribeIDs() {
+		r := w.research[tribe]
+		if r == nil {
+			r = &Research{}
+			w.research[tribe] = r
+		}
+		res := w.resources[tribe]
+		if res == nil {
+			res = &TribeResources{}
+			w.resources[tribe] = res
+		}
+
+		if r.Current != TechNone {
+			info := techTree[r.Current]
+			due := installment(info.Cost, r.Progress, info.Ticks)
+			if !res.canPay(due) {
+				continue // Paused until it can
+			}
+			res.pay(due)
+			r.Progress++
+			if r.Progress >= techTree[r.Current].Ticks {
+				r.Known = append(r.Known, r.Current)
+				r.Current = TechNone
+				r.Progress = 0
+			}
+			continue
+		}
+
+		if saving[tribe] {
+			continue
+		}
+
+		for _, t := range techOrder {
+			if !r.knows(t) && r.knows(techTree[t].Requires) {
+				r.Current = t
+				break
+			}
+		}
+	}
+}
+
+// Known techs (in finish order) and the one being researched, TechNone if idle
+func (w *World) TribeTechs(tribe uint8) (known []Tech, current Tech) {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+
+	r := w.research[tribe]
+	if r == nil {
+		return nil, TechNone
+	}
+
+	return append([]Tech(nil), r.Known...), r.Current
+}
+
+// Scatters ore veins over home flats, rarer and smaller than rocks
+func placeOreVeins(w *World, isHomeFlat func(TerrainType) bool) {
+	tries := placementTries * scaledCount(w, 12)
+	for i := 0; i < scaledCount(w, 12); i++ {
+		cx, cy, ok := randomFlatCell(w, isHomeFlat, &tries)
+		if !ok {
+			break // No flat left to put it on
+		}
+
+		cells := 5 + w.rng.Intn(5)
+		for j := 0; j < cells; j++ {
+			nx, ny := cx+w.rng.Intn(5)-2, cy+w.rng.Intn(5)-2
+			if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height && isHomeFlat(TerrainType(w.Terrain[ny*w.Width+nx])) {
+				w.Terrain[ny*w.Width+nx] = uint8(TerrainOre)
+			}
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package world
+
+import "testing"
+
+func TestMineOre(t *testing.T) {
+	w := newTestWorld(t, 1, "r", "1")
+	w.Terrain[0] = uint8(TerrainOre)
+	w.EntityStats.MoveChance = 0
+	w.resources[1] = &TribeResources{Food: 100}
+
+	w.PreWarUpdate()
+	if got := w.resources[1].Ore; got != 1 {
+		t.Fatalf("ore %d after mining a vein, want 1", got)
+	}
+	if got := TerrainType(w.Terrain[0]); got != TerrainRed {
+		t.Fatalf("mined vein became %d, want home flat", got)
+	}
+}
+
+func TestResearchPaysInInstallmentsAndFinishes(t *testing.T) {
+	w := newTestWorld(t, 1, "r", "")
+	iron := techTree[TechIronWorking]
+	w.resources[1] = &TribeResources{Wood: iron.Cost.Wood, Stone: iron.Cost.Stone, Ore: iron.Cost.Ore}
+
+	w.advanceResearch(map[uint8]bool{})
+	if _, current := w.TribeTechs(1); current != TechIronWorking {
+		t.Fatalf("researching %v, want iron working", current)
+	}
+
+	for i := int64(0); i < iron.Ticks/2; i++ {
+		w.advanceResearch(map[uint8]bool{})
+	}
+	if res := w.resources[1]; res.Wood != iron.Cost.Wood/2 || res.Ore != iron.Cost.Ore/2 {
+		t.Fatalf("resources %+v halfway through, want half the cost left", *res)
+	}
+
+	for i := int64(1); i < iron.Ticks/2; i++ {
+		w.advanceResearch(map[uint8]bool{})
+	}
+	if w.hasTech(1, TechIronWorking) {
+		t.Fatal("iron working finished a tick early")
+	}
+
+	w.advanceResearch(map[uint8]bool{})
+	if !w.hasTech(1, TechIronWorking) {
+		t.Fatal("iron working not finished after its research ticks")
+	}
+	if res := w.resources[1]; res.Wood != 0 || res.Stone != 0 || res.Ore != 0 {
+		t.Fatalf("resources %+v after finishing, want all spent", *res)
+	}
+}
+
+func TestResearchWaitsWhileSaving(t *testing.T) {
+	w := newTestWorld(t, 1, "r", "")
+	w.resources[1] = &TribeResources{Wood: 1000, Stone: 1000, Ore: 1000}
+
+	w.advanceResearch(map[uint8]bool{1: true})
+	if _, current := w.TribeTechs(1); current != TechNone {
+		t.Fatalf("tribe saving for a village started researching %v", current)
+	}
+}
+
+// Out of wood halfway, research holds where it is until more comes in
+func TestResearchPausesWhenBroke(t *testing.T) {
+	w := newTestWorld(t, 1, "r", "")
+	iron := techTree[TechIronWorking]
+	w.resources[1] = &TribeResources{Wood: iron.Cost.Wood / 2, Ore: iron.Cost.Ore}
+	w.research[1] = &Research{Current: TechIronWorking}
+
+	for i := int64(0); i < iron.Ticks; i++ {
+		w.advanceResearch(map[uint8]bool{})
+	}
+	r := w.research[1]
+	paused := r.Progress
+	if paused >= iron.Ticks || w.resources[1].Wood != 0 {
+		t.Fatalf("progress %d with %d wood left, want paused partway", paused, w.resources[1].Wood)
+	}
+	w.advanceResearch(map[uint8]bool{})
+	if r.Progress != paused {
+		t.Fatalf("progress went %d -> %d with no wood", paused, r.Progress)
+	}
+
+	w.resources[1].Wood = iron.Cost.Wood
+	for i := paused; i < iron.Ticks; i++ {
+		w.advanceResearch(map[uint8]bool{})
+	}
+	if !w.hasTech(1, TechIronWorking) {
+		t.Fatalf("iron working not finished once paid for, progress %d", r.Progress)
+	}
+}
+
+// A stone swordsman with plenty of ore only reaches iron once it's researched.
+func armWithOre(t *testing.T, r *Research) WeaponType {
+	t.Helper()
+
+	w := newTestWorld(t, 1, "rrr", ".1.")
+	w.EntityStats.MoveChance = 0
+	w.EntityStats.ReproductionRate = 0
+	ent := w.Entities[0][1]
+	ent.Weapon = WeaponStone
+	w.resources[1] = &TribeResources{Ore: 100, Food: 1000}
+	w.villages[0][0] = &Village{Tribe: 1, Level: 1} // Under capacity, so not saving
+	w.research[1] = r
+
+	for i := 0; i < 250; i++ {
+		w.PreWarUpdate()
+	}
+	return ent.Weapon
+}
+
+func TestIronNeedsTech(t *testing.T) {
+	if got := armWithOre(t, &Research{Current: TechIronWorking}); got != WeaponStone {
+		t.Fatalf("weapon %d without iron working, want stone", got)
+	}
+	if got := armWithOre(t, &Research{Known: []Tech{TechIronWorking}, Current: TechSteelMaking}); got != WeaponIron {
+		t.Fatalf("weapon %d with iron working, want iron", got)
+	}
+}
+
+func TestSnapshotKeepsResearch(t *testing.T) {
+	w := newMapWorld(t, 7, "vertical", 30, 30)
+	w.research[1] = &Research{Known: []Tech{TechIronWorking}, Current: TechSteelMaking, Progress: 42}
+
+	restored := roundTrip(t, w)
+
+	r := restored.research[1]
+	if r == nil || !r.knows(TechIronWorking) || r.Current != TechSteelMaking || r.Progress != 42 {
+		t.Fatalf("research after restore is %+v", r)
+	}
+}
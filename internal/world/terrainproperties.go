@@ -7,17 +7,23 @@ const (
     TerrainRed TerrainType = 1  // first tribe flat land (vertical and north v south)
     TerrainBlue TerrainType = 2  // second tribe flat land (vertical and north v south)
     TerrainBorder TerrainType = 4 // Neutral divider (crossable in war, avoided in peace)
-    TerrainTrees TerrainType = 6  // Impassable neutral (forest)
-    TerrainRocks TerrainType = 7  // Impassable neutral (mountains/rocks)
+    TerrainTrees TerrainType = 6  // Neutral forest, slow to cross (see MoveCost)
+    TerrainRocks TerrainType = 7  // Neutral mountains/rocks, slow to cross
     TerrainHills TerrainType = 8  // Slow movement (higher cost, passable)
     TerrainYellow TerrainType = 9 // 4 quadrant map specific
 	TerrainGreen TerrainType = 10 // 4 quadrant map specific
+	TerrainBerries TerrainType = 14 // Berry bushes, picked for food (passable, never cleared)
+	TerrainOre TerrainType = 15 // Ore vein, mined like rocks for iron/steel gear
+	TerrainWater TerrainType = 17 // Open water, only boats cross it (see boats.go)
+	TerrainFord TerrainType = 18 // Shallow crossing, wadeable but slow
+	TerrainFire TerrainType = 20 // Burning trees, impassable until it burns out (see fire.go)
+	TerrainBurnt TerrainType = 21 // Burnt ground, regrows into trees
 )
 
 // Returns true if entities can move onto this terrain
 func IsPassable(t TerrainType) bool {
 	switch t {
-	case TerrainEmpty, TerrainRed, TerrainBlue, TerrainBorder, TerrainHills, TerrainTrees, TerrainRocks, TerrainYellow, TerrainGreen:
+	case TerrainEmpty, TerrainRed, TerrainBlue, TerrainBorder, TerrainHills, TerrainTrees, TerrainRocks, TerrainYellow, TerrainGreen, TerrainBerries, TerrainOre, TerrainFord, TerrainBurnt:
 		return true
 
 	default:
@@ -44,6 +50,7 @@ func IsOwnTerrain(t TerrainType, w *World, tribe uint8) bool {
 	return false
 }
 
+// returns true if terrain is the home of a tribe we're at war with
 func IsEnemyTerrain(t TerrainType, w *World, tribe uint8) bool {
 	_, myOk := w.Tribes[tribe]
 	if !myOk {
@@ -51,7 +58,7 @@ func IsEnemyTerrain(t TerrainType, w *World, tribe uint8) bool {
 	}
 
 	for otherTribe, otherCfg := range w.Tribes {
-		if otherTribe != tribe && t == otherCfg.HomeTerrain {
+		if t == otherCfg.HomeTerrain && w.hostile(tribe, otherTribe) {
 			return true
 		}
 	}
@@ -64,7 +71,7 @@ func IsEnemyTerrain(t TerrainType, w *World, tribe uint8) bool {
 // used in both peace and war movement scoring
 func MoveScoreBonus(t TerrainType, w *World, myTribe uint8) float64 {
 	switch t {
-	case TerrainEmpty:
+	case TerrainEmpty, TerrainBurnt:
 		return -2.0
 
 	case TerrainBorder:
@@ -72,6 +79,9 @@ func MoveScoreBonus(t TerrainType, w *World, myTribe uint8) float64 {
 
 	case TerrainHills:
 		return -0.5
+
+	case TerrainFord:
+		return -1.0
 	}
 
 	if IsOwnTerrain(t, w, myTribe) {
@@ -0,0 +1,37 @@
+package world
+
+// Veterancy. The unit that lands the killing blow in war gets the kill, and
+// enough kills promote it (see Rank.KillsNeeded). Buying ranks with wood in
+// peace is an optional rule, EntityStats.BuyRanks
+func (r Rank) KillsNeeded() int {
+	switch r {
+	case RankSuper:
+		return 3
+
+	case RankMega:
+		return 8
+
+	default:
+		return 0
+	}
+}
+
+// Credits e with a kill and promotes it as far as its kills allow
+func (e *Entity) creditKill() {
+	e.Kills++
+	for e.Rank < RankMega && e.Kills >= (e.Rank+1).KillsNeeded() {
+		e.Rank++
+	}
+}
+
+func (w *World) SetBuyRanks(on bool) {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+	w.EntityStats.BuyRanks = on
+}
+
+func (w *World) BuyRanks() bool {
+	w.Mu.RLock()
+	defer w.Mu.RUnlock()
+	return w.EntityStats.BuyRanks
+}
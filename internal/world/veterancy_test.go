@@ -0,0 +1,68 @@
+package world
+
+import "testing"
+
+// The second hit takes the target past its health, so that attacker gets the kill
+func TestKillingBlowGetsTheKill(t *testing.T) {
+	w := newTestWorld(t, 1, "rbr", "121")
+	w.EntityStats.MoveChance = 0
+	first, target, second := w.Entities[0][0], w.Entities[0][1], w.Entities[0][2]
+	target.Health = first.TotalDamage(w) + 1
+	w.StartWar()
+
+	w.Update()
+	if w.Entities[0][1] != nil {
+		t.Fatal("target survived both hits")
+	}
+	if first.Kills != 0 || second.Kills != 1 || target.Kills != 0 {
+		t.Fatalf("kills %d/%d/%d, want 0/1/0", first.Kills, second.Kills, target.Kills)
+	}
+}
+
+func TestKillsPromote(t *testing.T) {
+	ent := &Entity{Rank: RankBase}
+	for i := 0; i < RankSuper.KillsNeeded(); i++ {
+		ent.creditKill()
+	}
+	if ent.Rank != RankSuper {
+		t.Fatalf("rank %v after %d kills, want Super", ent.Rank, ent.Kills)
+	}
+
+	for ent.Kills < RankMega.KillsNeeded() {
+		ent.creditKill()
+	}
+	if ent.Rank != RankMega {
+		t.Fatalf("rank %v after %d kills, want Mega", ent.Rank, ent.Kills)
+	}
+
+	ent.creditKill()
+	if ent.Rank != RankMega {
+		t.Fatalf("rank %v past Mega", ent.Rank)
+	}
+}
+
+func rankAfterPeace(t *testing.T, buy bool) Rank {
+	t.Helper()
+
+	w := newTestWorld(t, 1, "rrr", ".1.")
+	w.EntityStats.MoveChance = 0
+	w.EntityStats.ReproductionRate = 0
+	w.EntityStats.BuyRanks = buy
+	w.resources[1] = &TribeResources{Wood: 10000, Food: 1000}
+	w.villages[0][0] = &Village{Tribe: 1, Level: 1}
+	ent := w.Entities[0][1]
+
+	for i := 0; i < 500; i++ {
+		w.PreWarUpdate()
+	}
+	return ent.Rank
+}
+
+func TestBuyRanksIsOptional(t *testing.T) {
+	if got := rankAfterPeace(t, false); got != RankBase {
+		t.Fatalf("rank %v bought with wood while the rule is off", got)
+	}
+	if got := rankAfterPeace(t, true); got == RankBase {
+		t.Fatal("no rank bought with plenty of wood and the rule on")
+	}
+}
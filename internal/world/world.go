@@ -8,29 +8,53 @@ import (
 	"math"
 	"math/rand"
 	"runtime/debug"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
 )
 
-const GridSize = 100
+// Default map size, and the bounds a world can be resized to
+const (
+    DefaultGridSize = 100
+    MinGridSize = 10
+    MaxGridSize = 1024 // Width/height travel as uint16 in grid frames
+)
 
 type World struct {
 	Mu sync.RWMutex
-	Entities [][]*Entity // [GridSize][GridSize]*Entity or nil
+	Width int // Cells per row, fixed until the next Resize
+	Height int // Rows
+	Entities [][]*Entity // [Height][Width]*Entity or nil
     Terrain []uint8 // Separate layer: 0 (empty/bad), 1 (red/left), 2 (blue/right), 4 (green/border)
-    lastReprodTime [GridSize][GridSize]time.Time // Per-call last reprod tick
-    tickCount int64 // Global tick counter
+    lastReprodTick [][]int64 // Tick of last reprod per cell (0 = never)
+    tickCount int64 // Global tick counter, advanced once per Update (peace or war)
     baseTickInterval time.Duration // For cooldown calc (set to 250ms)
 	EntityStats EntityStats
     warStarted bool // false initially, set to true on client action
     winner string // "left", "right", "draw", etc..
     gameOver bool
     conversionRate float64 // chance per tick to convert enemy terrain nder entity (war only)
-    lastClearedTime [GridSize][GridSize]time.Time // Tracks when a tree was last cleared
+    lastClearedTick [][]int64 // Tick a tree was last cleared (0 = not cleared)
+    villages [][]*Village // Buildings per cell, see settlements.go
+    boats [][]*Boat // Boats on water cells, see boats.go
+    regrowTicks int64 // Ticks before a cleared tree grows back, before season and weather
+    weather Weather // Rolled every WeatherTicks, see seasons.go
     resources map[uint8]*TribeResources // Key: tribe ID (1, 2, etc.)
+    research map[uint8]*Research // Tech tree progress per tribe, see tech.go
+    relations map[[2]uint8]Relation // Non-war pairs only, see diplomacy.go
+    diplomacyAI bool // Let the AI form and break alliances in war
+    homeFields map[uint8]flowField // Per tribe, toward its own flats, see pathfind.go
+    attackFields map[uint8]flowField // Per tribe, toward its enemies (war only)
+    landingFields map[uint8]flowField // Per tribe, boats to shores it can fight from (war only)
+    dockFields map[uint8]flowField // Per tribe, empty boats to its stranded units (war only)
+    embarkFields map[uint8]flowField // Per tribe, stranded units to its boats (war only)
+    leaderFell map[uint8]int64 // Tick each tribe last lost its leader in war, see leader.go
     nextEntityID map[uint8]*uint32 // Per-tribe sequential ID counter
     Tribes map[uint8]TribeConfig // Active tribes + config for this map
+    seed int64 // Seed rng was built from, Reset rewinds to it
+    rng *rand.Rand // Per-world source, all sim randomness goes through this
+    rngSrc *countingSource // Backs rng, counts draws for snapshots
 }
 
 type TribeConfig struct {
@@ -40,33 +64,39 @@ type TribeConfig struct {
     Name string
     DamageBonus int // Racial passive: bonus damage for related races
     BaseEvasion float64 // Racial passive: bonus evasion for related races
+    Lifespan int64 // Ticks a unit lives, 0 = never ages (see aging.go)
     DefenseBonus int // Racial passive: bonus armor for related races
+    Sprites string // Client sprite set from the race definition
 }
 
 func New() *World {
-	rand.Seed(time.Now().UnixNano())
+	return NewWithSeed(time.Now().UnixNano())
+}
+
+// Same seed + same player actions = same battle
+func NewWithSeed(seed int64) *World {
 	w := &World{
-		Entities: make([][]*Entity, GridSize),
-        Terrain: make([]uint8, GridSize * GridSize),
-        lastReprodTime: [GridSize][GridSize]time.Time{},
         baseTickInterval: 250 * time.Millisecond,
+        regrowTicks: 80, // 20s at 1x speed
         warStarted: false,
         conversionRate: 0.20, // 20% chance per tick
 		EntityStats: EntityStats{
 			MoveChance: 0.2, // 20% move chance
 			ReproductionRate: 0.005, // 0.5% reproduction chance
             MaxDensityFraction: 0.030, // should be 4% but its 40% for some reason so dont go above 0.1%
-            ReprodCooldown: 1 * time.Minute, // 5 minutes
+            ReprodCooldownTicks: 240, // 1 minute at 1x speed
+            ArcherChance: 0.3,
 		},
 	}
 
-    for i := range w.Entities {
-        w.Entities[i] = make([]*Entity, GridSize)
-    }
-
-    w.lastClearedTime = [GridSize][GridSize]time.Time{}
+    w.allocLayers(DefaultGridSize, DefaultGridSize)
     w.resources = make(map[uint8]*TribeResources)
+    w.research = make(map[uint8]*Research)
+    w.relations = make(map[[2]uint8]Relation)
+    w.leaderFell = make(map[uint8]int64)
     w.nextEntityID = make(map[uint8]*uint32) // Start at 1 for each tribe
+    w.seed = seed
+    w.resetRNG(0)
 
     // Default to classic map
 	//w.InitMap("northsouth")
@@ -74,12 +104,76 @@ func New() *World {
 	return w
 }
 
+func newEntityLayer(width, height int) [][]*Entity {
+    layer := make([][]*Entity, height)
+    for i := range layer {
+        layer[i] = make([]*Entity, width)
+    }
+
+    return layer
+}
+
+func newTickLayer(width, height int) [][]int64 {
+    layer := make([][]int64, height)
+    for i := range layer {
+        layer[i] = make([]int64, width)
+    }
+
+    return layer
+}
+
+// (Re)allocates every per-cell layer for a width x height grid, all empty
+func (w *World) allocLayers(width, height int) {
+    w.Width = width
+    w.Height = height
+    w.Entities = newEntityLayer(width, height)
+    w.Terrain = make([]uint8, width * height)
+    w.lastReprodTick = newTickLayer(width, height)
+    w.lastClearedTick = newTickLayer(width, height)
+    w.villages = newVillageLayer(width, height)
+    w.boats = newBoatLayer(width, height)
+    w.dropFlowFields()
+}
+
+func ValidGridSize(width, height int) error {
+    if width < MinGridSize || width > MaxGridSize || height < MinGridSize || height > MaxGridSize {
+        return fmt.Errorf("grid size %dx%d out of range (%d..%d per side)", width, height, MinGridSize, MaxGridSize)
+    }
+
+    return nil
+}
+
+// Switches to a new grid size, wiping the world like Reset. Call InitMap after
+func (w *World) Resize(width, height int) error {
+    if err := ValidGridSize(width, height); err != nil {
+        return err
+    }
+
+    w.Mu.Lock()
+    defer w.Mu.Unlock()
+
+    w.allocLayers(width, height)
+    w.resetLocked()
+
+    return nil
+}
+
+func (w *World) Size() (width, height int) {
+    w.Mu.RLock()
+    defer w.Mu.RUnlock()
+    return w.Width, w.Height
+}
+
 // Color guide for world: 1 = red, 2 = blue, 3 = yellow, 4 = green
 
 func (w *World) InitMap(mapName string) {
     w.Mu.Lock()
     defer w.Mu.Unlock()
 
+    w.relations = make(map[[2]uint8]Relation) // New lineup, everyone back at war
+    w.leaderFell = make(map[uint8]int64)
+    w.dropFlowFields()
+
     switch mapName {
     case "vertical":
         InitVerticalSplit(w)
@@ -97,12 +191,16 @@ func (w *World) InitMap(mapName string) {
         w.Tribes = make(map[uint8]TribeConfig)
         log.Println("Custom map made")
 
-    // case "islands":
+    case "islands":
+        InitIslands(w)
 
     default:
         log.Printf("Unknow map '%s', falling back to vertical", mapName)
         InitVerticalSplit(w)
     }
+
+    w.placeStartingVillages()
+    w.stockStartingFood()
 }
 
 // Safe read for broadcasting
@@ -110,23 +208,45 @@ func (w *World) GetGridCopy() []uint8 {
 	w.Mu.RLock()
 	defer w.Mu.RUnlock()
 
-	copyGrid := make([]uint8, GridSize * GridSize)
-	for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+	copyGrid := make([]uint8, w.Width * w.Height)
+	w.fillGrid(copyGrid)
+
+	return copyGrid
+}
+
+// Writes the render code of every cell (entity viz code or terrain) into dst, caller holds Mu
+func (w *World) fillGrid(dst []uint8) {
+	for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
            ent := w.Entities[y][x]
-           if ent != nil {
+           if ent != nil && ent.Leader {
+                dst[y*w.Width + x] = LeaderVizCode(ent.Tribe)
+            } else if ent != nil {
                 if cfg, ok := w.Tribes[ent.Tribe]; ok {
-                    copyGrid[y * GridSize + x] = cfg.EntityVizCode
+                    dst[y*w.Width + x] = cfg.EntityVizCode
                 } else {
-                    copyGrid[y * GridSize + x] = 3 // Fallback unknown
+                    dst[y*w.Width + x] = 3 // Fallback unknown
                 }
+            } else if w.villages[y][x] != nil {
+                dst[y*w.Width + x] = VillageVizCode
+            } else if b := w.boats[y][x]; b != nil {
+                dst[y*w.Width + x] = BoatVizCode(b.Tribe)
             } else {
-                copyGrid[y * GridSize + x] = w.Terrain[y * GridSize + x]
+                dst[y*w.Width + x] = w.Terrain[y*w.Width + x]
             }
         }
     }
+}
 
-	return copyGrid
+// Tribe IDs in ascending order, map iteration order would break replays
+func (w *World) sortedTribeIDs() []uint8 {
+    ids := make([]uint8, 0, len(w.Tribes))
+    for tribe := range w.Tribes {
+        ids = append(ids, tribe)
+    }
+    sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+    return ids
 }
 
 func (w *World) GetTribeFromHomeTerrain(t TerrainType) (uint8, bool) {
@@ -157,27 +277,63 @@ func (w *World) IsGameOver() bool {
     return w.gameOver
 }
 
+// Current simulation tick (0 before the first Update)
+func (w *World) Tick() int64 {
+    w.Mu.RLock()
+    defer w.Mu.RUnlock()
+    return w.tickCount
+}
+
+func (w *World) Seed() int64 {
+    w.Mu.RLock()
+    defer w.Mu.RUnlock()
+    return w.seed
+}
+
+// Switches to a new seed, takes effect from the next Reset
+func (w *World) Reseed(seed int64) {
+    w.Mu.Lock()
+    defer w.Mu.Unlock()
+    w.seed = seed
+}
+
 func (w *World) Reset() {
     w.Mu.Lock()
     defer w.Mu.Unlock()
-    
+    w.resetLocked()
+}
+
+// Reset body, caller holds Mu
+func (w *World) resetLocked() {
     // Clear entities and cooldowns
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             w.Entities[y][x] = nil
-            w.lastReprodTime[y][x] = time.Time{}
-            w.lastClearedTime[y][x] = time.Time{}
+            w.lastReprodTick[y][x] = 0
+            w.lastClearedTick[y][x] = 0
+            w.villages[y][x] = nil
+            w.boats[y][x] = nil
         }
     }
 
-    // reset resources
+    // reset resources, with a full larder so units painted after a reset don't starve
     w.resources = make(map[uint8]*TribeResources)
+    w.stockStartingFood()
+    w.research = make(map[uint8]*Research)
+    w.relations = make(map[[2]uint8]Relation)
+    w.leaderFell = make(map[uint8]int64)
     w.nextEntityID = make(map[uint8]*uint32)
+    w.dropFlowFields()
 
     // reset state
     w.warStarted = false
     w.gameOver = false
     w.winner = ""
+    w.tickCount = 0
+    w.weather = WeatherClear
+
+    // Rewind rng so the next map + battle replays exactly
+    w.resetRNG(0)
     
     // Restore initial terrain and starting entities
     //w.InitMap("northsouth")
@@ -193,10 +349,11 @@ func (w *World) PreWarUpdate() {
         }
     }()
 
-    newEntities := make([][]*Entity, GridSize)
-    for i := range newEntities {
-        newEntities[i] = make([]*Entity, GridSize)
-    }
+    // All cooldowns/regrowth are measured against this, so speed and pause apply to them too
+    w.tickCount++
+    w.updateWeather()
+
+    newEntities := newEntityLayer(w.Width, w.Height)
     directions := [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} // Up, down, left, right
    
     // Phase 1: Collect potential moves
@@ -206,41 +363,57 @@ func (w *World) PreWarUpdate() {
     }
     potentialMoves := []PotentialMove{}
 
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    w.refreshFlowFields(false)
+
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             ent := w.Entities[y][x]
-            if ent != nil && rand.Float64() < w.EntityStats.MoveChance {
+            if ent != nil && w.rng.Float64() < w.moveChance() {
                 myTribe := ent.Tribe
+                ent.gainMovePoint()
 
                 // Mining impulse
                 resourceDirs := []int{}
                 for d, dir := range directions {
                     nx, ny := x + dir[0], y + dir[1]
-                    if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-                        targetTerrain := TerrainType(w.Terrain[ny*GridSize + nx])
-                        if w.Entities[ny][nx] == nil && (targetTerrain == TerrainTrees || targetTerrain == TerrainRocks) {
+                    if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+                        targetTerrain := TerrainType(w.Terrain[ny*w.Width + nx])
+                        ripe := targetTerrain == TerrainBerries && w.berriesReady(nx, ny)
+                        mineable := targetTerrain == TerrainTrees || targetTerrain == TerrainRocks || targetTerrain == TerrainOre
+                        if w.Entities[ny][nx] == nil && (mineable || ripe) {
                             resourceDirs = append(resourceDirs, d)
                         }
                     }
                 }
 
-                if len(resourceDirs) > 0 && rand.Float64() < 0.20 {
-                    d := resourceDirs[rand.Intn(len(resourceDirs))]
+                if len(resourceDirs) > 0 && w.rng.Float64() < 0.20 {
+                    d := resourceDirs[w.rng.Intn(len(resourceDirs))]
                     dir := directions[d]
                     nx, ny := x + dir[0], y + dir[1]
-                    potentialMoves = append(potentialMoves, PotentialMove{fromX: x, fromY: y, toX: nx, toY: ny})
+                    if w.canEnter(ent, nx, ny) { // Otherwise still working through the brush
+                        potentialMoves = append(potentialMoves, PotentialMove{fromX: x, fromY: y, toX: nx, toY: ny})
+                    }
                     continue
                 }
 
+                // Strays off home land follow the home field back
+                var homeSteps uint8
+                if !IsOwnTerrain(TerrainType(w.Terrain[y*w.Width + x]), w, myTribe) {
+                    homeSteps, _ = w.downhill(w.homeField(myTribe), x, y, directions)
+                }
+
                 // Normal scoring if not mining
                 bestScore := -1.0
                 bestDirs := []int{}
                 for d, dir := range directions {
                     nx, ny := x + dir[0], y + dir[1]
-                    if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-                        targetTerrain := TerrainType(w.Terrain[ny*GridSize + nx])
+                    if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+                        targetTerrain := TerrainType(w.Terrain[ny*w.Width + nx])
                         if w.Entities[ny][nx] == nil && IsPassable(targetTerrain) {
                             score := MoveScoreBonus(targetTerrain, w, myTribe)
+                            if homeSteps&(1<<d) != 0 {
+                                score += PathPull
+                            }
                             if score > bestScore {
                                 bestScore = score
                                 bestDirs = []int{d}
@@ -252,18 +425,20 @@ func (w *World) PreWarUpdate() {
                 }
 
                 if bestScore > 0 && len(bestDirs) > 0 {
-                    d := bestDirs[rand.Intn(len(bestDirs))]
+                    d := bestDirs[w.rng.Intn(len(bestDirs))]
                     dir := directions[d]
                     nx, ny := x + dir[0], y + dir[1]
-                    potentialMoves = append(potentialMoves, PotentialMove{fromX: x, fromY: y, toX: nx, toY: ny})
+                    if w.canEnter(ent, nx, ny) {
+                        potentialMoves = append(potentialMoves, PotentialMove{fromX: x, fromY: y, toX: nx, toY: ny})
+                    }
                 }
             }
         }
     }
 
     // Initially set all entities to stay
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             newEntities[y][x] = w.Entities[y][x]
         }
     }
@@ -271,37 +446,43 @@ func (w *World) PreWarUpdate() {
     // Phase 2: Resolve move conflicts (move cooldowns with entities; terrain preserved)
     type TargetKey struct { tx, ty int }
     targetMovers := make(map[TargetKey][]PotentialMove)
+    targetOrder := []TargetKey{} // First-seen order, keeps rng draws replayable
 
     for _, pm := range potentialMoves {
         key := TargetKey{tx: pm.toX, ty: pm.toY}
+        if _, seen := targetMovers[key]; !seen {
+            targetOrder = append(targetOrder, key)
+        }
         targetMovers[key] = append(targetMovers[key], pm)
     }
 
-    for _, movers := range targetMovers {
+    for _, key := range targetOrder {
+        movers := targetMovers[key]
         if len(movers) > 0 {
             // Random winner
-            winner := movers[rand.Intn(len(movers))]
+            winner := movers[w.rng.Intn(len(movers))]
             // Apply move
+            w.payMove(w.Entities[winner.fromY][winner.fromX], winner.toX, winner.toY)
             newEntities[winner.toY][winner.toX] = w.Entities[winner.fromY][winner.fromX]
             newEntities[winner.fromY][winner.fromX] = nil // Terrain stays
            
             // Move cooldown to new position
-            w.lastReprodTime[winner.toY][winner.toX] = w.lastReprodTime[winner.fromY][winner.fromX]
+            w.lastReprodTick[winner.toY][winner.toX] = w.lastReprodTick[winner.fromY][winner.fromX]
            
             // Reset old position
-            w.lastReprodTime[winner.fromY][winner.fromX] = time.Time{}
+            w.lastReprodTick[winner.fromY][winner.fromX] = 0
             // Losers stay (already set, including their cooldowns)
         }
     }
 
     // Phase 3: Reproduction
-    currentTime := time.Now()
-    cooldownDur := w.EntityStats.ReprodCooldown
+    currentTick := w.tickCount
+    cooldownTicks := w.EntityStats.ReprodCooldownTicks
 
     // Count per tribe
     tribeCounts := make(map[uint8]int)
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             ent := newEntities[y][x]
             if ent != nil {
                 tribeCounts[ent.Tribe]++
@@ -311,10 +492,10 @@ func (w *World) PreWarUpdate() {
 
     // Skip repro per tribe if over global density fraction
     skipReprod := make(map[uint8]bool)
-    totalCells := GridSize * GridSize
+    totalCells := w.Width * w.Height
     for tribe, count := range tribeCounts {
         density := float64(count) / float64(totalCells)
-        skipReprod[tribe] = density > w.EntityStats.MaxDensityFraction
+        skipReprod[tribe] = density > w.EntityStats.MaxDensityFraction || w.larderEmpty(tribe) // No food, no children
     }
 
     // Collect spawns without applying yet
@@ -324,28 +505,34 @@ func (w *World) PreWarUpdate() {
     }
     spawns := []Spawn{}
     
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             ent := newEntities[y][x]
             if ent != nil {
                 if skipReprod[ent.Tribe] {
                     continue
                 }
 
-                last := w.lastReprodTime[y][x]
-                if !last.IsZero() && currentTime.Sub(last) < cooldownDur {
+                last := w.lastReprodTick[y][x]
+                if last != 0 && currentTick - last < cooldownTicks {
                     continue // Cooldown active
                 }
+
+                // Only near an own village, bigger villages breed faster
+                level := w.nearVillageLevel(x, y, ent.Tribe)
+                if level == 0 {
+                    continue
+                }
                
-                if rand.Float64() < w.EntityStats.ReproductionRate {
-                    rand.Shuffle(len(directions), func(i, j int) { directions[i], directions[j] = directions[j], directions[i]})
+                if w.rng.Float64() < w.birthRate() * float64(level) {
+                    w.rng.Shuffle(len(directions), func(i, j int) { directions[i], directions[j] = directions[j], directions[i]})
                     for _, dir := range directions {
                         nx, ny := x + dir[0], y + dir[1]
-                        if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
-                            targetTerrain := TerrainType(w.Terrain[ny * GridSize + nx])
+                        if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
+                            targetTerrain := TerrainType(w.Terrain[ny*w.Width + nx])
                             if newEntities[ny][nx] == nil && CanReproduceOn(targetTerrain, w, ent.Tribe) {
                                 spawns = append(spawns, Spawn{nx: nx, ny: ny, tribe: ent.Tribe})
-                                w.lastReprodTime[y][x] = currentTime // Set parent cooldown
+                                w.lastReprodTick[y][x] = currentTick // Set parent cooldown
                                 // Approx density update
                                 tribeCounts[ent.Tribe]++
                                 density := float64(tribeCounts[ent.Tribe]) / float64(totalCells)
@@ -361,40 +548,55 @@ func (w *World) PreWarUpdate() {
         }
     }
 
+    // Phase 3.5: Settlements (tribes saving for a village don't arm)
+    saving := w.growSettlements(newEntities, tribeCounts)
+
+    // Phase 3.6: Research (no new projects while saving)
+    w.advanceResearch(saving)
+
+    // Phase 3.7: Leaders for tribes that can spare the food
+    w.crownLeaders(newEntities, false, saving)
+
+    // Phase 3.8: Boats for tribes by the water
+    w.buildBoats(newEntities, saving)
+
     // Phase 4: Arming and crafting
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
-            idx := w.Terrain[y * GridSize + x]
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
+            idx := w.Terrain[y*w.Width + x]
             ent := newEntities[y][x]
             if ent != nil {
                 cfg, ok := w.Tribes[ent.Tribe]
-                if !ok {
+                if !ok || saving[ent.Tribe] {
                     continue
                 }
 
                 terrain := TerrainType(idx)
-                if terrain == cfg.HomeTerrain && rand.Float64() < 0.02 {
+                if terrain == cfg.HomeTerrain && w.rng.Float64() < 0.02 {
                     res := w.resources[ent.Tribe]
                     if res == nil {
                         continue
                     }
 
-                    if ent.Weapon == WeaponNone && res.Wood >= 3 {
-                        res.Wood -= 3
-                        ent.Weapon = WeaponWood
-                    } else if ent.Weapon == WeaponWood && res.Stone >= 3 {
-                        res.Stone -= 3
-                        ent.Weapon = WeaponStone
+                    if ent.Weapon == WeaponNone && w.rng.Float64() < w.EntityStats.ArcherChance {
+                        // Would-be archers save up for a bow rather than settle for a sword
+                        if res.Wood >= BowWoodCost {
+                            res.Wood -= BowWoodCost
+                            ent.Weapon = WeaponBow
+                        }
+                    } else if up, ok := weaponUpgrades[ent.Weapon]; ok && w.hasTech(ent.Tribe, up.needs) && res.canPay(up.cost) {
+                        res.pay(up.cost)
+                        ent.Weapon = up.next
                     }
 
-                    if ent.Armor == ArmorNone && res.Wood >= 5 {
-                        res.Wood -= 5
-                        ent.Armor = ArmorWood
-                    } else if ent.Armor == ArmorWood && res.Stone >= 5 {
-                        res.Stone -= 5
-                        ent.Armor = ArmorStone
+                    if up, ok := armorUpgrades[ent.Armor]; ok && w.hasTech(ent.Tribe, up.needs) && res.canPay(up.cost) {
+                        res.pay(up.cost)
+                        ent.Armor = up.next
                     }
 
+                    if !w.EntityStats.BuyRanks {
+                        continue // Ranks come from kills only
+                    }
                     if ent.Rank == RankBase && res.Wood >= RankSuper.UpgradeCost() {
                         res.Wood -= RankSuper.UpgradeCost()
                         ent.Rank = RankSuper    
@@ -425,12 +627,19 @@ func (w *World) PreWarUpdate() {
             ID: id,
             Evasion: cfg.BaseEvasion,
             Rank: RankBase,
+            Morale: MaxMorale,
+            Born: currentTick,
         }
-        w.lastReprodTime[s.ny][s.nx] = currentTime // Set child cooldown to match
+        w.lastReprodTick[s.ny][s.nx] = currentTick // Set child cooldown to match
     }
 
     w.Entities = newEntities
     HandleMiningAndRegrowth(w)
+    deaths := w.burnFires(w.Entities, nil)
+    deaths = w.eatMeals(w.Entities, deaths, true)
+    deaths = w.ageEntities(w.Entities, deaths)
+    w.peaceMorale(w.Entities, deaths)
+    w.mournLeaders(w.Entities, deaths)
 }
 
 // SiMulation update tick
@@ -459,10 +668,13 @@ func (w *World) Update() {
         return
     }
 
-    newEntities := make([][]*Entity, GridSize)
-    for i := range newEntities {
-        newEntities[i] = make([]*Entity, GridSize)
-    }
+    w.tickCount++
+    w.updateWeather()
+
+    // Alliances shift before anyone picks a target
+    w.runDiplomacyAI(w.Entities)
+
+    newEntities := newEntityLayer(w.Width, w.Height)
     directions := [][2]int{{0, -1}, {0, 1}, {-1, 0}, {1, 0}} // Up, down, left, right
    
     // Pre-compute enemy centers per tribe
@@ -471,8 +683,8 @@ func (w *World) Update() {
         Count int
     }
     tribeCenters := make(map[uint8]TribeCenter)
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             ent := w.Entities[y][x]
             if ent != nil {
                 tc := tribeCenters[ent.Tribe]
@@ -486,7 +698,14 @@ func (w *World) Update() {
 
     // Compute actual centers
     centers := make(map[uint]struct{ CX, CY float64 })
-    for tribe, tc := range tribeCenters {
+    centerOrder := []uint{} // Fixed summation order so float results replay exactly
+    for tribe := range tribeCenters {
+        centerOrder = append(centerOrder, uint(tribe))
+    }
+    sort.Slice(centerOrder, func(i, j int) bool { return centerOrder[i] < centerOrder[j] })
+    for _, t := range centerOrder {
+        tribe := uint8(t)
+        tc := tribeCenters[tribe]
         if tc.Count > 0 {
             centers[uint(tribe)] = struct{ CX, CY float64 }{
                 CX: tc.XSum / float64(tc.Count),
@@ -495,6 +714,11 @@ func (w *World) Update() {
         }
     }
    
+    w.refreshFlowFields(true)
+
+    // Leaderless tribes promote their best unit
+    w.crownLeaders(w.Entities, true, nil)
+
     // Phase 1: Collect potential moves
     type PotentialMove struct {
         fromX, fromY int
@@ -502,10 +726,10 @@ func (w *World) Update() {
     }
     potentialMoves := []PotentialMove{}
 
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             ent := w.Entities[y][x]
-            if ent == nil || rand.Float64() >= w.EntityStats.MoveChance {
+            if ent == nil || w.rng.Float64() >= w.moveChance() {
                 continue
             }
 
@@ -514,13 +738,15 @@ func (w *World) Update() {
             if !myOk {
                 continue // Unknown tribe safety
             }
+            ent.gainMovePoint()
 
-            // Compute enemy center: average of all other tribes' centers
+            // Compute enemy center: average of the centers of tribes we're at war with
             var enemyXSum, enemyYSum float64
             var enemyTotalCount int
             hasEnemies := false
-            for otherTribe, otherCenter := range centers {
-                if otherTribe != uint(myTribe) {
+            for _, otherTribe := range centerOrder {
+                otherCenter, ok := centers[otherTribe]
+                if ok && w.hostile(myTribe, uint8(otherTribe)) {
                     otherTC := tribeCenters[uint8(otherTribe)]
                     enemyXSum += otherCenter.CX * float64(otherTC.Count)
                     enemyYSum += otherCenter.CY * float64(otherTC.Count)
@@ -540,20 +766,47 @@ func (w *World) Update() {
                 currentDist = math.Abs(float64(x) - enemyCX) + math.Abs(float64(y) - enemyCY)
             }
 
+            // Routed units head home, the rest toward the nearest enemy
+            var fieldSteps uint8
+            onField := false
+            if ent.Routed() {
+                fieldSteps, onField = w.downhill(w.homeField(myTribe), x, y, directions)
+            } else {
+                fieldSteps, onField = w.downhill(w.attackField(myTribe), x, y, directions)
+                if !onField && w.stranded(ent, x, y) {
+                    fieldSteps, onField = w.downhill(w.embarkField(myTribe), x, y, directions) // No way by land, make for the boats
+                }
+            }
+
             bestScore := -1.0
             bestDirs := []int{}
 
             for d, dir := range directions {
                 nx, ny := x + dir[0], y + dir[1]
-                if nx < 0 || nx >= GridSize || ny < 0 || ny >= GridSize {
+                if nx < 0 || nx >= w.Width || ny < 0 || ny >= w.Height {
                     continue
                 }
 
-                targetTerrain := TerrainType(w.Terrain[ny * GridSize + nx])
+                targetTerrain := TerrainType(w.Terrain[ny*w.Width + nx])
                 if w.Entities[ny][nx] != nil || !IsPassable(targetTerrain) {
                     continue
                 }
 
+                // Routed units fall back home instead of pressing on
+                if ent.Routed() {
+                    score := w.retreatScore(w.Entities, nx, ny, myTribe, currentDist, enemyCX, enemyCY, hasEnemies)
+                    if fieldSteps&(1<<d) != 0 {
+                        score += PathPull
+                    }
+                    if score > bestScore {
+                        bestScore = score
+                        bestDirs = []int{d}
+                    } else if score == bestScore {
+                        bestDirs = append(bestDirs, d)
+                    }
+                    continue
+                }
+
                 score := MoveScoreBonus(targetTerrain, w, myTribe)
 
                 // Stron invasion bonus for stepping on enemy flat
@@ -566,9 +819,9 @@ func (w *World) Update() {
                 for edy := -1; edy <= 1; edy++ {
                     for edx := -1; edx <= 1; edx++ {
                         ex, ey := nx + edx, ny + edy
-                        if ex >= 0 && ex < GridSize && ey >= 0 && ey < GridSize {
+                        if ex >= 0 && ex < w.Width && ey >= 0 && ey < w.Height {
                             enemyEnt := w.Entities[ey][ex]
-                            if enemyEnt != nil && enemyEnt.Tribe != myTribe {
+                            if enemyEnt != nil && w.hostile(myTribe, enemyEnt.Tribe) {
                                 localEnemies++
                             }
                         }
@@ -582,8 +835,8 @@ func (w *World) Update() {
                 for edy := -1; edy <= 1; edy++ {
                     for edx := -1; edx <= 1; edx++ {
                         ex, ey := nx + edx, ny + edy
-                        if ex >= 0 && ex < GridSize && ey >= 0 && ey < GridSize {
-                            if IsEnemyTerrain(TerrainType(w.Terrain[ey*GridSize + ex]), w, myTribe) {
+                        if ex >= 0 && ex < w.Width && ey >= 0 && ey < w.Height {
+                            if IsEnemyTerrain(TerrainType(w.Terrain[ey*w.Width + ex]), w, myTribe) {
                                 frontierBonus++
                             }
                         }
@@ -591,8 +844,13 @@ func (w *World) Update() {
                 }
                 score += float64(frontierBonus) * 3.0
 
-                // Global pull (weakened, only if closer)
-                if hasEnemies {
+                // Global pull along the attack field, around ridges and forests.
+                // Straight-line centroid pull (weakened, only if closer) when no path reaches here
+                if onField {
+                    if fieldSteps&(1<<d) != 0 {
+                        score += PathPull
+                    }
+                } else if hasEnemies {
                     newDist := math.Abs(float64(nx)-enemyCX) + math.Abs(float64(ny)-enemyCY)
                     reduction := currentDist - newDist
                     if reduction > 0 {
@@ -609,17 +867,19 @@ func (w *World) Update() {
             }
 
             if bestScore > 0 && len(bestDirs) > 0 {
-                d := bestDirs[rand.Intn(len(bestDirs))]
+                d := bestDirs[w.rng.Intn(len(bestDirs))]
                 dir := directions[d]
                 nx, ny := x + dir[0], y + dir[1]
-                potentialMoves = append(potentialMoves, PotentialMove{fromX: x, fromY: y, toX: nx, toY: ny})
+                if w.canEnter(ent, nx, ny) { // Not enough points yet, keeps saving them
+                    potentialMoves = append(potentialMoves, PotentialMove{fromX: x, fromY: y, toX: nx, toY: ny})
+                }
             }
         }
     }
 
     // Initially set all entities to stay
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             newEntities[y][x] = w.Entities[y][x]
         }
     }
@@ -627,43 +887,74 @@ func (w *World) Update() {
     // Phase 2: Resolve move conflicts
     type TargetKey struct { tx, ty int }
     targetMovers := make(map[TargetKey][]PotentialMove)
-    
+    targetOrder := []TargetKey{} // First-seen order, keeps rng draws replayable
+
     for _, pm := range potentialMoves {
         key := TargetKey{tx: pm.toX, ty: pm.toY}
+        if _, seen := targetMovers[key]; !seen {
+            targetOrder = append(targetOrder, key)
+        }
         targetMovers[key] = append(targetMovers[key], pm)
     }
 
-    for _, movers := range targetMovers {
+    for _, key := range targetOrder {
+        movers := targetMovers[key]
         if len(movers) > 0 {
-            winner := movers[rand.Intn(len(movers))]
+            winner := movers[w.rng.Intn(len(movers))]
+            w.payMove(w.Entities[winner.fromY][winner.fromX], winner.toX, winner.toY)
             newEntities[winner.toY][winner.toX] = w.Entities[winner.fromY][winner.fromX]
             newEntities[winner.fromY][winner.fromX] = nil
-            w.lastReprodTime[winner.toY][winner.toX] = w.lastReprodTime[winner.fromY][winner.fromX]
-            w.lastReprodTime[winner.fromY][winner.fromX] = time.Time{}
+            w.lastReprodTick[winner.toY][winner.toX] = w.lastReprodTick[winner.fromY][winner.fromX]
+            w.lastReprodTick[winner.fromY][winner.fromX] = 0
         }
     }
 
+    // Stranded units take to the boats, boats sail and land them
+    w.buildBoats(newEntities, nil)
+    w.sailBoats(newEntities)
+
     // Phase 2.5: Fighting
-    damageAccum := make([][]int, GridSize)
+    w.applyAuras(newEntities)
+    damageAccum := make([][]int, w.Height)
     for i := range damageAccum {
-        damageAccum[i] = make([]int, GridSize)
+        damageAccum[i] = make([]int, w.Width)
+    }
+
+    // Whoever's hit takes a cell's damage past its health gets the kill
+    killers := newEntityLayer(w.Width, w.Height)
+    hit := func(attacker *Entity, tx, ty, dmg int) {
+        damageAccum[ty][tx] += dmg
+        target := newEntities[ty][tx]
+        if killers[ty][tx] == nil && damageAccum[ty][tx] - target.TotalArmor(w) >= target.Health {
+            killers[ty][tx] = attacker
+        }
     }
 
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             ent := newEntities[y][x]
             if ent != nil {
                 // Method that inclues racial damage
                 myDmg := ent.TotalDamage(w)
 
+                // Archers loose one arrow a tick at the nearest enemy they can see
+                if reach := ent.Weapon.Range(); reach > 1 {
+                    if tx, ty, ok := archerTarget(w, newEntities, x, y, reach); ok {
+                        if w.rng.Float64() >= newEntities[ty][tx].Evasion {
+                            hit(ent, tx, ty, myDmg)
+                        }
+                    }
+                    continue
+                }
+
                 for _, dir := range directions {
                     nx, ny := x + dir[0], y + dir[1]
-                    if nx >= 0 && nx < GridSize && ny >= 0 && ny < GridSize {
+                    if nx >= 0 && nx < w.Width && ny >= 0 && ny < w.Height {
                         neighbor := newEntities[ny][nx]
-                        if neighbor != nil && neighbor.Tribe != ent.Tribe {
+                        if neighbor != nil && w.hostile(ent.Tribe, neighbor.Tribe) {
                             // Evasion check for hit or not
-                            if rand.Float64() >= neighbor.Evasion {
-                                damageAccum[ny][nx] += myDmg
+                            if w.rng.Float64() >= neighbor.Evasion {
+                                hit(ent, nx, ny, myDmg)
                             }
                         }
                     }
@@ -673,8 +964,9 @@ func (w *World) Update() {
     }
 
     // Apply damage and deaths
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    var deaths []death
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             ent := newEntities[y][x]
             if ent != nil {
                 incoming := damageAccum[y][x]
@@ -685,49 +977,63 @@ func (w *World) Update() {
                 }
                 ent.Health -= effectiveDmg
                 if ent.Health <= 0 {
+                    if killer := killers[y][x]; killer != nil {
+                        killer.creditKill()
+                    }
+                    deaths = append(deaths, death{x, y, ent.Tribe, ent.Leader})
                     newEntities[y][x] = nil
-                    w.lastReprodTime[y][x] = time.Time{}
+                    w.lastReprodTick[y][x] = 0
                 }
             }
         }
     }
 
     // Phase 3: Terrain conversion (war only — only flat enemy land)
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             ent := newEntities[y][x]
             if ent != nil {
-                terrain := TerrainType(w.Terrain[y*GridSize + x])
-                if IsEnemyTerrain(terrain, w, ent.Tribe) && rand.Float64() < w.conversionRate {
+                terrain := TerrainType(w.Terrain[y*w.Width + x])
+                if IsEnemyTerrain(terrain, w, ent.Tribe) && w.rng.Float64() < w.conversionRate {
                     cfg, ok := w.Tribes[ent.Tribe]
                     if ok {
-                        w.Terrain[y * GridSize + x] = uint8(cfg.HomeTerrain)
+                        w.Terrain[y*w.Width + x] = uint8(cfg.HomeTerrain)
                     }
                 }
             }
         }
     }
 
+    // Villages under enemy feet can fall
+    w.contestVillages(newEntities)
+
     // Attrition / regen on harsh terrain
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             ent := newEntities[y][x] // Use final w.Entities after moves/combat/conversion
             if ent != nil {
-                terrain := TerrainType(w.Terrain[y*GridSize + x])
-                if terrain == TerrainHills || terrain == TerrainRocks || terrain == TerrainTrees {
-                    // Attrition: lose health on hills (harsh terrain tires troops)
-                    ent.Health -= 3 // Adjust this value — 3 feels noticeable but not instant death
+                terrain := TerrainType(w.Terrain[y*w.Width + x])
+                if terrain == TerrainHills || terrain == TerrainRocks || terrain == TerrainTrees || terrain == TerrainOre {
+                    // Attrition: lose health on hills (harsh terrain tires troops), worse in winter and storms
+                    ent.Health -= w.attrition()
                     if ent.Health <= 0 {
                         ent.Health = 0
+                        deaths = append(deaths, death{x, y, ent.Tribe, ent.Leader})
                         newEntities[y][x] = nil
-                        w.lastReprodTime[y][x] = time.Time{}
+                        w.lastReprodTick[y][x] = 0
                     }
                 } else {
                     // Regen when off hills (back to full strength)
-                    if ent.Health < 100 {
+                    if ent.Health < ent.MaxHealth() {
                         ent.Health += 3 // Faster regen off hills — quickly back to ~100
-                        if ent.Health > 100 {
-                            ent.Health = 100
+                        if ent.Routed() && IsOwnTerrain(terrain, w, ent.Tribe) {
+                            ent.Health += RetreatRegen // Regrouping at home
+                        }
+                        if ent.inspired {
+                            ent.Health += LeaderRegen
+                        }
+                        if ent.Health > ent.MaxHealth() {
+                            ent.Health = ent.MaxHealth()
                         }
                     }
                 }
@@ -735,43 +1041,88 @@ func (w *World) Update() {
         }
     }
 
+    // Forest fires spread and burn whoever's in or next to them
+    deaths = w.burnFires(newEntities, deaths)
+
+    // Old age, after regen so it can't heal past the cap
+    deaths = w.ageEntities(newEntities, deaths)
+
+    // Armies still have to be fed, farms and what's left in the larder do it
+    deaths = w.eatMeals(newEntities, deaths, false)
+
+    w.updateMorale(newEntities, deaths)
+    w.mournLeaders(newEntities, deaths)
+
     w.Entities = newEntities
 
+    // Burnt ground and cleared trees grow back, on cells the war has left empty
+    regrowTrees(w)
+
     // Victory Detection + Full Terrain Conquest (leaves border + natural features)
     aliveCounts := make(map[uint8]int)
-    for y := 0; y < GridSize; y++ {
-        for x := 0; x < GridSize; x++ {
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
             ent := w.Entities[y][x]
             if ent != nil {
                 aliveCounts[ent.Tribe]++
             }
         }
     }
+    w.countPassengers(aliveCounts) // Still at sea, still in the war
 
-    aliveTribes := 0
-    var winnerTribe uint8
-    for tribe, count := range aliveCounts {
-        if count > 0 {
-            aliveTribes++
-            winnerTribe = tribe
+    alive := []uint8{}
+    for _, tribe := range w.sortedTribeIDs() {
+        if aliveCounts[tribe] > 0 {
+            alive = append(alive, tribe)
         }
     }
 
-    if aliveTribes == 0 {
+    // Still a war until the survivors are all allies, peace alone doesn't share a win
+    for i, a := range alive {
+        for _, b := range alive[i+1:] {
+            if w.relation(a, b) != RelationAlliance {
+                return
+            }
+        }
+    }
+
+    w.gameOver = true
+    if len(alive) == 0 {
         w.winner = "draw"
-        w.gameOver = true
-    } else if aliveTribes == 1 {
-        w.winner = fmt.Sprintf("%d", winnerTribe)
-        w.gameOver = true
-
-        winnerCfg, ok := w.Tribes[winnerTribe]
-        if ok {
-            winnerHome := winnerCfg.HomeTerrain
-            for i := range w.Terrain {
-                t := TerrainType(w.Terrain[i])
-                if IsEnemyTerrain(t, w, winnerTribe) {
-                    w.Terrain[i] = uint8(winnerHome)
-                }
+        return
+    }
+    w.winner = coalitionName(alive)
+
+    // The biggest survivor takes the land and villages of the fallen
+    lead := alive[0]
+    for _, tribe := range alive[1:] {
+        if aliveCounts[tribe] > aliveCounts[lead] {
+            lead = tribe
+        }
+    }
+    leadCfg, ok := w.Tribes[lead]
+    if !ok {
+        return
+    }
+
+    fallen := make(map[uint8]bool)
+    fallenHomes := make(map[TerrainType]bool)
+    for tribe, cfg := range w.Tribes {
+        if aliveCounts[tribe] == 0 {
+            fallen[tribe] = true
+            fallenHomes[cfg.HomeTerrain] = true
+        }
+    }
+
+    for i := range w.Terrain {
+        if fallenHomes[TerrainType(w.Terrain[i])] {
+            w.Terrain[i] = uint8(leadCfg.HomeTerrain)
+        }
+    }
+    for y := 0; y < w.Height; y++ {
+        for x := 0; x < w.Width; x++ {
+            if v := w.villages[y][x]; v != nil && fallen[v.Tribe] {
+                v.Tribe = lead
             }
         }
     }
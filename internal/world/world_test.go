@@ -0,0 +1,266 @@
+package world
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Four entities that can only step into the same cell, exactly one may win
+const contestedTerrain = `
+rrr#b
+rrr#b
+rrr#b
+`
+
+const contestedEntities = `
+111#.
+1.1#.
+111#2
+`
+
+func checkContestedMove(t *testing.T, w *World, before map[*Entity][2]int) {
+	t.Helper()
+
+	assertOneCellPerEntity(t, w)
+	after := entityPositions(w)
+	if len(after) != len(before) {
+		t.Fatalf("entity count %d -> %d", len(before), len(after))
+	}
+
+	center := w.Entities[1][1]
+	if center == nil {
+		t.Fatal("nobody moved into the contested cell")
+	}
+	from := before[center]
+	if from == [2]int{1, 1} {
+		t.Fatal("contested cell was occupied before the tick")
+	}
+	if w.Entities[from[1]][from[0]] != nil {
+		t.Fatalf("winner's old cell (%d,%d) still occupied", from[0], from[1])
+	}
+
+	moved := 0
+	for ent, pos := range before {
+		if after[ent] != pos {
+			moved++
+		}
+	}
+	if moved != 1 && moved != 2 { // The lone tribe 2 entity may also step
+		t.Fatalf("%d entities moved, want the contested winner (+ maybe tribe 2)", moved)
+	}
+}
+
+func TestPreWarContestedMoveHasOneWinner(t *testing.T) {
+	for seed := int64(1); seed <= 50; seed++ {
+		w := newTestWorld(t, seed, contestedTerrain, contestedEntities)
+		w.EntityStats.MoveChance = 1
+		w.EntityStats.ReproductionRate = 0
+
+		before := entityPositions(w)
+		w.PreWarUpdate()
+		checkContestedMove(t, w, before)
+	}
+}
+
+func TestWarContestedMoveHasOneWinner(t *testing.T) {
+	for seed := int64(1); seed <= 50; seed++ {
+		w := newTestWorld(t, seed, contestedTerrain, contestedEntities)
+		w.EntityStats.MoveChance = 1
+		w.StartWar()
+
+		before := entityPositions(w)
+		w.Update()
+		checkContestedMove(t, w, before)
+	}
+}
+
+// No reproduction and no war: moves must never create, lose or clone entities
+func TestPreWarMovesConserveEntities(t *testing.T) {
+	for _, mapName := range []string{"vertical", "fourquadrants"} {
+		for seed := int64(1); seed <= 5; seed++ {
+			w := newMapWorld(t, seed, mapName, 40, 30)
+			w.EntityStats.MoveChance = 0.8
+			w.EntityStats.ReproductionRate = 0
+
+			want := entityPositions(w)
+			for i := 0; i < 100; i++ {
+				w.PreWarUpdate()
+				assertOneCellPerEntity(t, w)
+
+				got := entityPositions(w)
+				if len(got) != len(want) {
+					t.Fatalf("%s seed %d tick %d: %d entities, want %d", mapName, seed, w.tickCount, len(got), len(want))
+				}
+				for ent := range want {
+					if _, ok := got[ent]; !ok {
+						t.Fatalf("%s seed %d tick %d: entity %d of tribe %d vanished", mapName, seed, w.tickCount, ent.ID, ent.Tribe)
+					}
+				}
+			}
+		}
+	}
+}
+
+// In war nothing spawns, so every entity after a tick was there before it
+func TestWarNeverCreatesEntities(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		w := newMapWorld(t, seed, "fourquadrants", 40, 30)
+		w.ConvertBordersToTerrain()
+		w.StartWar()
+
+		for i := 0; i < 300 && !w.IsGameOver(); i++ {
+			before := entityPositions(w)
+			w.Update()
+			assertOneCellPerEntity(t, w)
+
+			for ent := range entityPositions(w) {
+				if _, ok := before[ent]; !ok {
+					t.Fatalf("seed %d tick %d: entity %d of tribe %d appeared from nowhere", seed, w.tickCount, ent.ID, ent.Tribe)
+				}
+			}
+		}
+	}
+}
+
+// Armor bigger than the incoming hit must still cost health, never add it
+func TestDamageNeverHeals(t *testing.T) {
+	w := newTestWorld(t, 1, `
+hh
+`, `
+12
+`)
+	w.EntityStats.MoveChance = 0
+	tribes := testTribes()
+	cfg := tribes[2]
+	cfg.DefenseBonus = 50
+	tribes[2] = cfg
+	w.Tribes = tribes
+	w.StartWar()
+
+	tank := w.Entities[0][1]
+	for i := 0; i < 10 && !w.IsGameOver(); i++ {
+		before := tank.Health
+		w.Update()
+		if tank.Health >= before {
+			t.Fatalf("tick %d: armored entity went %d -> %d under attack", w.tickCount, before, tank.Health)
+		}
+	}
+}
+
+// On harsh terrain there's no regen, so whoever ends a tick there can't have gained health
+func TestHealthNeverRisesOnHarshTerrain(t *testing.T) {
+	for seed := int64(1); seed <= 5; seed++ {
+		w := newMapWorld(t, seed, "vertical", 40, 30)
+		w.ConvertBordersToTerrain()
+		w.StartWar()
+
+		for i := 0; i < 300 && !w.IsGameOver(); i++ {
+			health := make(map[*Entity]int)
+			for ent := range entityPositions(w) {
+				health[ent] = ent.Health
+			}
+
+			w.Update()
+
+			for ent, pos := range entityPositions(w) {
+				switch TerrainType(w.Terrain[pos[1]*w.Width+pos[0]]) {
+				case TerrainHills, TerrainRocks, TerrainTrees:
+					if ent.Health >= health[ent] {
+						t.Fatalf("seed %d tick %d: entity on harsh terrain at (%d,%d) went %d -> %d", seed, w.tickCount, pos[0], pos[1], health[ent], ent.Health)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestVictoryDeclaredOnce(t *testing.T) {
+	w := newTestWorld(t, 1, `
+rrbb
+rrbb
+`, `
+.12.
+....
+`)
+	w.EntityStats.MoveChance = 0
+	tribes := testTribes()
+	cfg := tribes[1]
+	cfg.DamageBonus = 500
+	tribes[1] = cfg
+	w.Tribes = tribes
+	w.StartWar()
+
+	declared := 0
+	var frozen []byte
+	var frozenTick int64
+	for i := 0; i < 20; i++ {
+		wasOver := w.IsGameOver()
+		w.Update()
+
+		if !wasOver && w.IsGameOver() {
+			declared++
+			frozen = w.GetGridCopy()
+			frozenTick = w.Tick()
+			continue
+		}
+		if wasOver {
+			if w.Tick() != frozenTick {
+				t.Fatalf("tick advanced after victory: %d -> %d", frozenTick, w.Tick())
+			}
+			if !bytes.Equal(w.GetGridCopy(), frozen) {
+				t.Fatal("grid changed after victory")
+			}
+		}
+	}
+
+	if declared != 1 {
+		t.Fatalf("victory declared %d times, want 1", declared)
+	}
+	if w.GetWinner() != "1" {
+		t.Fatalf("winner %q, want \"1\"", w.GetWinner())
+	}
+	if n := w.CountTerrain(uint8(TerrainBlue)); n != 0 {
+		t.Fatalf("%d loser cells left after full conquest", n)
+	}
+}
+
+// Real battles too: once over, the result never changes
+func TestBattleResultIsFinal(t *testing.T) {
+	for seed := int64(1); seed <= 3; seed++ {
+		w := newMapWorld(t, seed, "northsouth", 20, 20)
+		for i := 0; i < 100; i++ {
+			w.Update()
+		}
+		w.ConvertBordersToTerrain()
+		w.StartWar()
+
+		for i := 0; i < 20000 && !w.IsGameOver(); i++ {
+			w.Update()
+		}
+		if !w.IsGameOver() {
+			t.Fatalf("seed %d: no winner after 20000 war ticks", seed)
+		}
+
+		winner, tick := w.GetWinner(), w.Tick()
+		for i := 0; i < 10; i++ {
+			w.Update()
+		}
+		if w.GetWinner() != winner || w.Tick() != tick {
+			t.Fatalf("seed %d: result %q@%d changed to %q@%d", seed, winner, tick, w.GetWinner(), w.Tick())
+		}
+	}
+}
+
+func TestSameSeedReplays(t *testing.T) {
+	run := func() []byte {
+		w := newMapWorld(t, 42, "fourquadrants", 30, 30)
+		for i := 0; i < 200; i++ {
+			w.Update()
+		}
+		return w.GetGridCopy()
+	}
+
+	if !bytes.Equal(run(), run()) {
+		t.Fatal("same seed produced different grids")
+	}
+}
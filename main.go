@@ -3,6 +3,7 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/Scrimzay/worldboxsim/internal/server"
 	"github.com/Scrimzay/worldboxsim/internal/world"
@@ -12,16 +13,26 @@ import (
 func main() {
     log.Println("=== STARTING WORLDBOX SIM ===")
     
-    // Init world
-    log.Println("Creating world...")
-    gameWorld := world.New()
-    log.Println("World created!")
-    
-    // Start broadcaster in background
-    log.Println("Creating broadcaster...")
-    broadcaster := world.NewBroadcaster(gameWorld)
-    log.Println("Starting broadcaster...")
-    go broadcaster.Run()
+    // Each room owns its own world + broadcaster, created on demand by /play/:mapName
+    log.Println("Creating room manager...")
+    rooms := server.NewRoomManager(10 * time.Minute)
+    go rooms.RunJanitor(1 * time.Minute)
+    log.Println("Room manager started!")
+
+    // Optional races file replaces the built-in race definitions
+    if racesFile := os.Getenv("RACES_FILE"); racesFile != "" {
+        if err := world.LoadRaceConfig(racesFile); err != nil {
+            log.Fatal("Races config invalid: ", err)
+        }
+        log.Printf("Loaded races from %s (%d races)", racesFile, len(world.Races().Races))
+    }
+
+    // Named world snapshots for save_world/load_world
+    snapshotDir := os.Getenv("SNAPSHOT_DIR")
+    if snapshotDir == "" {
+        snapshotDir = "snapshots"
+    }
+    snapshots := server.NewSnapshotStore(snapshotDir)
     
     // Get port form env (Koyeb sets this)
     port := os.Getenv("PORT")
@@ -31,7 +42,7 @@ func main() {
 
     // Setup and start server
     log.Println("Setting up router...")
-    r := server.SetupRouter(broadcaster, gameWorld)
+    r := server.SetupRouter(rooms, snapshots)
     log.Printf("Server starting at port %s", port)
     if err := r.Run(":" + port); err != nil {
         log.Fatal("Server failed:", err)
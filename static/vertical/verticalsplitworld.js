@@ -2,10 +2,11 @@ const canvas = document.getElementById('world');
 const ctx = canvas.getContext('2d');
 canvas.width = window.innerWidth;
 canvas.height = window.innerHeight;
-const GRID_SIZE = 100;
-let CELL_SIZE = Math.floor(Math.min(canvas.width, canvas.height) / GRID_SIZE);
-let offsetX = Math.floor((canvas.width - CELL_SIZE * GRID_SIZE) / 2);
-let offsetY = Math.floor((canvas.height - CELL_SIZE * GRID_SIZE) / 2);
+let GRID_W = 100; // Grid dimensions, updated from each binary frame header
+let GRID_H = 100;
+let CELL_SIZE = Math.floor(Math.min(canvas.width / GRID_W, canvas.height / GRID_H));
+let offsetX = Math.floor((canvas.width - CELL_SIZE * GRID_W) / 2);
+let offsetY = Math.floor((canvas.height - CELL_SIZE * GRID_H) / 2);
 let world = [];
 let previousWorld = []; // Track previous frame for movement detection
 let stats = { left: 0, right: 0, speed: 1.0, paused: false };
@@ -16,6 +17,12 @@ const speeds = [0.25, 0.5, 1, 2, 5, 10];
 const minSpeed = speeds[0];
 const maxSpeed = speeds[speeds.length - 1];
 let currentTool = 3;
+let currentPower = null; // Armed god power, replaces the tool until another tool is picked
+const POWER_RADIUS = { meteor: 3, earthquake: 5, lightning: 0, plague: 4, fire: 2 };
+const POWER_COLORS = { meteor: '255, 110, 0', earthquake: '150, 110, 60', lightning: '255, 255, 170', plague: '120, 200, 60', fire: '255, 69, 0' };
+const POWER_EFFECT_MS = 800;
+let powerEffects = []; // Strikes being animated, from god_power events
+let animatingPowers = false;
 let brushSize = 1;
 let fillMode = false;
 const MAX_FILL_SIZE = 1000;
@@ -54,7 +61,7 @@ function getBiomeFromTerrain(terrainType) {
 
 function getBiomeAt(x, y) {
     // Check the actual terrain type at this position
-    const idx = y * GRID_SIZE + x;
+    const idx = y * GRID_W + x;
     const cell = world[idx];
     
     // If it's a terrain cell (1 or 2), use that
@@ -71,8 +78,8 @@ function getBiomeAt(x, y) {
         for (let dx = -1; dx <= 1; dx++) {
             const nx = x + dx;
             const ny = y + dy;
-            if (nx >= 0 && nx < GRID_SIZE && ny >= 0 && ny < GRID_SIZE) {
-                const neighborIdx = ny * GRID_SIZE + nx;
+            if (nx >= 0 && nx < GRID_W && ny >= 0 && ny < GRID_H) {
+                const neighborIdx = ny * GRID_W + nx;
                 const neighborCell = world[neighborIdx];
                 if (neighborCell === 1) redCount++;
                 if (neighborCell === 2) blueCount++;
@@ -85,8 +92,8 @@ function getBiomeAt(x, y) {
     if (blueCount > redCount) return BIOMES.GRASSLAND;
     
     // Fallback to x-position if unclear
-    if (x > 50) return BIOMES.GRASSLAND;
-    if (x < 50) return BIOMES.DESERT;
+    if (x > GRID_W / 2) return BIOMES.GRASSLAND;
+    if (x < GRID_W / 2) return BIOMES.DESERT;
     return null; // Border
 }
 
@@ -174,17 +181,23 @@ loadEntityImage('desert', 'right', '/static/vertical/desertentity1.png');
 loadEntityImage('desert', 'left', '/static/vertical/desertentity2.png');
 
 function updateViewport() {
-    const baseSize = Math.floor(Math.min(canvas.width, canvas.height) / GRID_SIZE);
+    const baseSize = Math.floor(Math.min(canvas.width / GRID_W, canvas.height / GRID_H));
     CELL_SIZE = Math.floor(baseSize * zoom);
     
-    const gridPixelWidth = CELL_SIZE * GRID_SIZE;
-    const gridPixelHeight = CELL_SIZE * GRID_SIZE;
+    const gridPixelWidth = CELL_SIZE * GRID_W;
+    const gridPixelHeight = CELL_SIZE * GRID_H;
     offsetX = Math.floor((canvas.width - gridPixelWidth) / 2) + panOffsetX;
     offsetY = Math.floor((canvas.height - gridPixelHeight) / 2) + panOffsetY;
 }
 
 function setTool(tool) {
     currentTool = tool;
+    currentPower = null;
+    updateToolButtons();
+}
+
+function setPower(power) {
+    currentPower = power;
     updateToolButtons();
 }
 
@@ -210,12 +223,15 @@ function updateToolButtons() {
     const toolButtons = document.querySelectorAll('[data-tool]');
     toolButtons.forEach(btn => {
         const toolValue = parseInt(btn.getAttribute('data-tool'));
-        if (toolValue === currentTool) {
+        if (toolValue === currentTool && !currentPower) {
             btn.classList.add('active');
         } else {
             btn.classList.remove('active');
         }
     });
+    document.querySelectorAll('[data-power]').forEach(btn => {
+        btn.classList.toggle('active', btn.getAttribute('data-power') === currentPower);
+    });
 }
 
 function updateBrushButtons() {
@@ -237,9 +253,9 @@ function draw() {
     // Detect entity movements between frames
     if (previousWorld.length === world.length) {
         // Find entities and track their movement
-        for (let y = 0; y < GRID_SIZE; y++) {
-            for (let x = 0; x < GRID_SIZE; x++) {
-                const idx = y * GRID_SIZE + x;
+        for (let y = 0; y < GRID_H; y++) {
+            for (let x = 0; x < GRID_W; x++) {
+                const idx = y * GRID_W + x;
                 const cell = world[idx];
                 const prevCell = previousWorld[idx];
                 
@@ -252,11 +268,11 @@ function draw() {
                     let foundDirection = null;
                     
                     // Check left
-                    if (x > 0 && previousWorld[y * GRID_SIZE + (x - 1)] === cell && world[y * GRID_SIZE + (x - 1)] !== cell) {
+                    if (x > 0 && previousWorld[y * GRID_W + (x - 1)] === cell && world[y * GRID_W + (x - 1)] !== cell) {
                         foundDirection = 'right'; // Moved from left to right
                     }
                     // Check right
-                    else if (x < GRID_SIZE - 1 && previousWorld[y * GRID_SIZE + (x + 1)] === cell && world[y * GRID_SIZE + (x + 1)] !== cell) {
+                    else if (x < GRID_W - 1 && previousWorld[y * GRID_W + (x + 1)] === cell && world[y * GRID_W + (x + 1)] !== cell) {
                         foundDirection = 'left'; // Moved from right to left
                     }
                     
@@ -273,9 +289,9 @@ function draw() {
     }
 
     // Draw the grid
-    for (let y = 0; y < GRID_SIZE; y++) {
-        for (let x = 0; x < GRID_SIZE; x++) {
-            const cell = world[y * GRID_SIZE + x];
+    for (let y = 0; y < GRID_H; y++) {
+        for (let x = 0; x < GRID_W; x++) {
+            const cell = world[y * GRID_W + x];
             if (cell === 0) continue;
 
             let img = null;
@@ -331,6 +347,15 @@ function draw() {
                 if (cell === 2) color = '#228B22';     // Grassland
                 if (cell === 3) color = '#FFFF00';     // Red entity
                 if (cell === 4) color = '#0044ff';     // Border
+                if (cell === 13) color = '#8B5A2B';    // Village
+                if (cell === 14) color = '#8E3A59';    // Berry bushes
+                if (cell === 15) color = '#6E7B8B';    // Ore
+                if (markerTribe(cell, LEADER_VIZ_BASE)) color = '#FFD700'; // Leader
+                if (cell === 17) color = '#1E5AA8';    // Water
+                if (cell === 18) color = '#6FA8DC';    // Ford
+                if (markerTribe(cell, BOAT_VIZ_BASE)) color = '#8B4513'; // Boat
+                if (cell === 20) color = '#FF4500';    // Fire
+                if (cell === 21) color = '#3B3030';    // Burnt ground
                 if (cell === 5) color = 'white';       // Blue entity
                 if (cell === 6) {
                     color = biome === BIOMES.GRASSLAND ? '#004400' : '#8B4513';
@@ -344,19 +369,37 @@ function draw() {
 
                 ctx.fillStyle = color;
                 ctx.fillRect(offsetX + x * CELL_SIZE, offsetY + y * CELL_SIZE, CELL_SIZE, CELL_SIZE);
+
+                // Leaders and boats carry their tribe's colour in the middle
+                const marked = markerTribe(cell, LEADER_VIZ_BASE) || markerTribe(cell, BOAT_VIZ_BASE);
+                if (marked) {
+                    ctx.fillStyle = TRIBE_TINTS[marked] || '#333';
+                    ctx.fillRect(offsetX + x * CELL_SIZE + CELL_SIZE / 4, offsetY + y * CELL_SIZE + CELL_SIZE / 4, CELL_SIZE / 2, CELL_SIZE / 2);
+                }
             }
         }
     }
     
+    // Season and weather tint over the whole map
+    for (const tint of [SEASON_TINTS[season], WEATHER_TINTS[weather]]) {
+        if (tint) {
+            ctx.fillStyle = tint;
+            ctx.fillRect(offsetX, offsetY, GRID_W * CELL_SIZE, GRID_H * CELL_SIZE);
+        }
+    }
+
+    drawPowerEffects();
+
     // Store current world as previous for next frame
     previousWorld = [...world];
 }
 
-// WebSocket connection
+// WebSocket connection (room id comes from the /play/:mapName?room=... URL)
+const roomID = new URLSearchParams(window.location.search).get('room') || '';
 console.log('Window location:', window.location);
 console.log('Host:', window.location.host);
 console.log('Protocol:', window.location.protocol);
-console.log('Full WebSocket URL:', `wss://${window.location.host}/wss`);
+console.log('Full WebSocket URL:', `wss://${window.location.host}/wss?room=${encodeURIComponent(roomID)}`);
 
 // Test if the server endpoint exists first
 fetch(`https://${window.location.host}/wss`, { method: 'OPTIONS' })
@@ -364,13 +407,83 @@ fetch(`https://${window.location.host}/wss`, { method: 'OPTIONS' })
   .catch(err => console.log('WSS endpoint check failed:', err));
 
 //Then try the WebSocket connection
-const ws = new WebSocket(`wss://${window.location.host}/wss`);
+// Binary grid frame: [type u8 | 0x80 edit flag][tick u32 BE][width u16 BE][height u16 BE] then
+// type 1 (keyframe): width*height cells
+// type 2 (delta): [span count u32] + spans of [start u32][length u16][cells],
+// zero spans on a tick that changed nothing so every tick frame still arrives
+const FRAME_FULL_GRID = 1;
+const FRAME_GRID_DELTA = 2;
+const FRAME_FLAG_EDIT = 0x80; // Player edit between ticks, same tick as the last frame
+let lastFrameTick = -1;
+let frameSkip = 1; // Ticks between frames at the current speed, from stats
+let season = 'Spring'; // From stats, tints the map
+let weather = 'Clear';
+// Leaders and boats arrive as a base code plus their tribe ID (1..MAX_TRIBES)
+const LEADER_VIZ_BASE = 32;
+const BOAT_VIZ_BASE = 40;
+const MAX_TRIBES = 4;
+const TRIBE_TINTS = { 1: '#FFFF00', 2: 'white' }; // Same as each tribe's entity colour
+function markerTribe(cell, base) {
+    return cell > base && cell <= base + MAX_TRIBES ? cell - base : 0;
+}
+const SEASON_TINTS = {
+    Summer: 'rgba(255, 210, 80, 0.08)',
+    Autumn: 'rgba(200, 100, 20, 0.12)',
+    Winter: 'rgba(225, 240, 255, 0.25)',
+};
+const WEATHER_TINTS = {
+    Rain: 'rgba(40, 60, 110, 0.12)',
+    Storm: 'rgba(15, 15, 35, 0.30)',
+};
+let droppedFrames = 0;
+
+function applyGridFrame(buffer) {
+    const view = new DataView(buffer);
+    const type = view.getUint8(0) & ~FRAME_FLAG_EDIT;
+    const tick = view.getUint32(1);
+    const width = view.getUint16(5);
+    const height = view.getUint16(7);
+    if (!(view.getUint8(0) & FRAME_FLAG_EDIT)) {
+        if (lastFrameTick >= 0 && tick > lastFrameTick + frameSkip) {
+            droppedFrames += Math.floor((tick - lastFrameTick) / frameSkip) - 1;
+            console.warn(`[WS] Frames dropped between tick ${lastFrameTick} and ${tick} (${droppedFrames} total)`);
+        }
+        lastFrameTick = tick;
+    }
+    if (width !== GRID_W || height !== GRID_H) {
+        GRID_W = width;
+        GRID_H = height;
+        previousWorld = [];
+        entityDirections = {};
+        updateViewport();
+    }
+
+    if (type === FRAME_FULL_GRID) {
+        world = Array.from(new Uint8Array(buffer, 9));
+    } else if (type === FRAME_GRID_DELTA) {
+        // Patch a copy so previousWorld (movement detection) still sees the old frame
+        world = world.slice();
+        const bytes = new Uint8Array(buffer);
+        const spanCount = view.getUint32(9);
+        let pos = 13;
+        for (let i = 0; i < spanCount; i++) {
+            const start = view.getUint32(pos);
+            const length = view.getUint16(pos + 4);
+            pos += 6;
+            for (let j = 0; j < length; j++) {
+                world[start + j] = bytes[pos + j];
+            }
+            pos += length;
+        }
+    }
+}
+
+const ws = new WebSocket(`wss://${window.location.host}/wss?room=${encodeURIComponent(roomID)}`);
 ws.binaryType = 'arraybuffer';
 ws.onopen = () => console.log('WS connected');
 ws.onmessage = (event) => {
     if (event.data instanceof ArrayBuffer) {
-        const data = new Uint8Array(event.data);
-        world = Array.from(data);
+        applyGridFrame(event.data);
     } else {
         let msg;
         try {
@@ -380,6 +493,31 @@ ws.onmessage = (event) => {
             return;
         }
 
+        if (msg.season !== undefined) {
+            season = msg.season;
+            weather = msg.weather;
+        }
+        if (msg.frameSkip !== undefined) {
+            if (msg.frameSkip !== frameSkip) lastFrameTick = -1; // Speed changed, don't count the gap
+            frameSkip = msg.frameSkip;
+        }
+        if (msg.tick !== undefined && msg.tick < lastFrameTick) {
+            lastFrameTick = -1; // World was reset or a snapshot loaded
+        }
+
+        if (msg.action === 'god_power') {
+            powerEffects.push({ ...msg, start: performance.now() });
+            if (!animatingPowers) {
+                animatingPowers = true;
+                requestAnimationFrame(animatePowers);
+            }
+            return;
+        }
+        if (msg.action === 'god_power_response' && !msg.ok) {
+            console.warn('God power failed:', msg.error);
+            return;
+        }
+
         if (msg.action === 'inspect_response') {
             const popup = document.getElementById('inspectPopup');
             if (msg.empty) {
@@ -396,14 +534,20 @@ ws.onmessage = (event) => {
                     rankText += ` (${bonuses.join(', ')})`;
                 }
                 document.getElementById('entityRank').innerText = rankText;
+                document.getElementById('entityKills').innerText = msg.kills || 0;
                 
                 document.getElementById('entityHealth').innerText = msg.health || 100;
+                document.getElementById('entityMorale').innerText = (msg.morale ?? 100) + (msg.routed ? ' (retreating)' : '');
+                document.getElementById('entityAge').innerText = (msg.age || 0) + (msg.lifespan ? ' / ' + msg.lifespan : '');
                 
                 // Display weapon with damage
                 let weaponText = msg.weapon || 'None';
                 if (msg.weapon && msg.weapon !== 'None') {
-                    const weaponDmg = msg.weapon === 'Wood Sword' ? 3 : 4;
+                    const weaponDmg = msg.weaponDamage !== undefined ? msg.weaponDamage : (msg.weapon === 'Wood Sword' ? 3 : 4);
                     weaponText = `${msg.weapon} (+${weaponDmg} dmg)`;
+                    if (msg.range > 1) {
+                        weaponText += `, range ${msg.range}`;
+                    }
                 }
                 document.getElementById('entityWeapon').innerText = weaponText;
                 
@@ -483,7 +627,7 @@ ws.onmessage = (event) => {
             }
 
             if (msg.winner) {
-                let winnerName = tribeNames[msg.winner] || `Tribe ${msg.winner}`;
+                let winnerName = msg.winner.split('+').map(id => tribeNames[id] || `Tribe ${id}`).join(' & '); // Allies win together as "1+3"
                 if (msg.winner === "draw") {
                     winnerName = "Draw";
                 }
@@ -510,6 +654,10 @@ canvas.addEventListener('mousedown', (e) => {
         return;
     }
     
+    if (e.button === 0 && currentPower) {
+        castPower(e);
+        return;
+    }
     if (e.button === 0) {
         isDrawing = true;
         tryPlace(e);
@@ -555,7 +703,7 @@ canvas.addEventListener('contextmenu', (e) => {
     const gridX = Math.floor(mouseX / CELL_SIZE);
     const gridY = Math.floor(mouseY / CELL_SIZE);
 
-    if (gridX >= 0 && gridX < GRID_SIZE && gridY >= 0 && gridY < GRID_SIZE) {
+    if (gridX >= 0 && gridX < GRID_W && gridY >= 0 && gridY < GRID_H) {
         ws.send(JSON.stringify({ action: 'inspect', x: gridX, y: gridY }));
         lastClickX = e.clientX;
         lastClickY = e.clientY;
@@ -660,6 +808,38 @@ canvas.addEventListener('wheel', (e) => {
     }
 });
 
+function castPower(e) {
+    const rect = canvas.getBoundingClientRect();
+    const x = Math.floor((e.clientX - rect.left - offsetX) / CELL_SIZE);
+    const y = Math.floor((e.clientY - rect.top - offsetY) / CELL_SIZE);
+    if (x >= 0 && x < GRID_W && y >= 0 && y < GRID_H) {
+        ws.send(JSON.stringify({ action: 'god_power', power: currentPower, x, y, radius: POWER_RADIUS[currentPower] }));
+    }
+}
+
+// Expanding, fading disc over the cells a god power hit
+function drawPowerEffects() {
+    const now = performance.now();
+    powerEffects = powerEffects.filter(fx => now - fx.start < POWER_EFFECT_MS);
+    for (const fx of powerEffects) {
+        const t = (now - fx.start) / POWER_EFFECT_MS;
+        const r = (fx.radius + 0.5) * CELL_SIZE * (0.3 + 0.7 * t);
+        ctx.fillStyle = `rgba(${POWER_COLORS[fx.power] || '255, 255, 255'}, ${0.6 * (1 - t)})`;
+        ctx.beginPath();
+        ctx.arc(offsetX + (fx.x + 0.5) * CELL_SIZE, offsetY + (fx.y + 0.5) * CELL_SIZE, r, 0, 2 * Math.PI);
+        ctx.fill();
+    }
+}
+
+function animatePowers() {
+    draw();
+    if (powerEffects.length > 0) {
+        requestAnimationFrame(animatePowers);
+    } else {
+        animatingPowers = false;
+    }
+}
+
 function tryPlace(e) {
     const now = Date.now();
     if (now - lastPlaceTime < PLACE_DELAY) return;
@@ -682,7 +862,7 @@ function tryPlace(e) {
             for (let dx = -radius; dx <= radius; dx++) {
                 const x = centerX + dx;
                 const y = centerY + dy;
-                if (x >= 0 && x < GRID_SIZE && y >= 0 && y < GRID_SIZE) {
+                if (x >= 0 && x < GRID_W && y >= 0 && y < GRID_H) {
                     places.push({x, y});
                 }
             }
@@ -729,7 +909,7 @@ updateToolButtons();
 updateBrushButtons();
 
 function floodFill(startX, startY, fillType) {
-    const startIdx = startY * GRID_SIZE + startX;
+    const startIdx = startY * GRID_W + startX;
     const targetType = world[startIdx];
     if (targetType !== 0 && targetType !== 1 && targetType !== 2) {
         console.warn('Fill start not terrain (1/2) - value:', targetType);
@@ -744,14 +924,14 @@ function floodFill(startX, startY, fillType) {
         const key = `${x},${y}`;
         if (visited.has(key)) continue;
         visited.add(key);
-        const idx = y * GRID_SIZE + x;
+        const idx = y * GRID_W + x;
         if (world[idx] === targetType) {
             places.push({x, y});
             filledCount++;
             if (x > 0) queue.push([x - 1, y]);
-            if (x < GRID_SIZE - 1) queue.push([x + 1, y]);
+            if (x < GRID_W - 1) queue.push([x + 1, y]);
             if (y > 0) queue.push([x, y - 1]);
-            if (y < GRID_SIZE - 1) queue.push([x, y + 1]);
+            if (y < GRID_H - 1) queue.push([x, y + 1]);
         }
     }
     console.log('Found', filledCount, 'cells to fill');